@@ -0,0 +1,74 @@
+// Command tga inspects TGA files.
+//
+// Usage:
+//
+//	tga layout FILE...
+//
+// layout prints the byte ranges of every section of the files, and the gaps
+// between them. Sections running past the end of a file are marked as such.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fesiqueira/tga"
+)
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "layout" {
+		fmt.Fprintln(os.Stderr, "usage: tga layout FILE...")
+		os.Exit(2)
+	}
+
+	status := 0
+
+	for _, name := range os.Args[2:] {
+		err := layout(os.Stdout, name, len(os.Args) > 3)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tga: %s: %v\n", name, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func layout(w io.Writer, name string, title bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// sections are listed from the offsets the file records, even when
+	// they are broken
+	sections, err := tga.ReadLayout(f)
+	if err != nil {
+		return err
+	}
+
+	if title {
+		fmt.Fprintf(w, "%s:\n", name)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OFFSET\tEND\tLENGTH\t\tSECTION")
+
+	for _, s := range sections {
+		name := s.Name
+		if s.Gap {
+			name = "(gap)"
+		}
+
+		if s.OutOfRange {
+			name += " (past the end of the file)"
+		}
+
+		fmt.Fprintf(tw, "%d\t%d\t%d\t\t%s\n", s.Offset, s.End(), s.Length, name)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,110 @@
+package tga
+
+import "image/color"
+
+// Color models for the pixel formats TGA stores.
+var (
+	BGR888Model    color.Model = color.ModelFunc(bgr888Model)
+	BGRA8888Model  color.Model = color.ModelFunc(bgra8888Model)
+	ARGB1555Model  color.Model = color.ModelFunc(argb1555Model)
+	GrayAlphaModel color.Model = color.ModelFunc(grayAlphaModel)
+)
+
+// BGR888 is a 24-bit color, in the order Targa 24 stores it.
+type BGR888 struct {
+	B, G, R uint8
+}
+
+func (c BGR888) RGBA() (r, g, b, a uint32) {
+	r = uint32(c.R) * 0x101
+	g = uint32(c.G) * 0x101
+	b = uint32(c.B) * 0x101
+	return r, g, b, 0xffff
+}
+
+// BGRA8888 is a non-premultiplied 32-bit color, in the order Targa 32
+// stores it.
+type BGRA8888 struct {
+	B, G, R, A uint8
+}
+
+func (c BGRA8888) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}.RGBA()
+}
+
+// ARGB1555 is a 16-bit color as Targa 16 stores it, little-endian: 5 bits
+// per channel with red in the high bits, and the attribute bit on top used
+// as a 1-bit alpha.
+type ARGB1555 uint16
+
+func (c ARGB1555) RGBA() (r, g, b, a uint32) {
+	if c&0x8000 == 0 {
+		return 0, 0, 0, 0
+	}
+
+	r = expand5(uint32(c>>10) & 0x1f)
+	g = expand5(uint32(c>>5) & 0x1f)
+	b = expand5(uint32(c) & 0x1f)
+	return r, g, b, 0xffff
+}
+
+// expand5 scales a 5-bit channel to 16 bits.
+func expand5(v uint32) uint32 {
+	v = v<<3 | v>>2
+	return v * 0x101
+}
+
+// GrayAlpha is a non-premultiplied 8-bit gray with 8 bits of alpha, as
+// 16-bit black-and-white images store it.
+type GrayAlpha struct {
+	Y, A uint8
+}
+
+func (c GrayAlpha) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{R: c.Y, G: c.Y, B: c.Y, A: c.A}.RGBA()
+}
+
+func bgr888Model(c color.Color) color.Color {
+	if c, ok := c.(BGR888); ok {
+		return c
+	}
+
+	r, g, b, _ := c.RGBA()
+	return BGR888{B: uint8(b >> 8), G: uint8(g >> 8), R: uint8(r >> 8)}
+}
+
+func bgra8888Model(c color.Color) color.Color {
+	if c, ok := c.(BGRA8888); ok {
+		return c
+	}
+
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return BGRA8888{B: n.B, G: n.G, R: n.R, A: n.A}
+}
+
+func argb1555Model(c color.Color) color.Color {
+	if c, ok := c.(ARGB1555); ok {
+		return c
+	}
+
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+
+	v := ARGB1555(n.R>>3)<<10 | ARGB1555(n.G>>3)<<5 | ARGB1555(n.B>>3)
+	if n.A >= 0x80 {
+		v |= 0x8000
+	}
+
+	return v
+}
+
+func grayAlphaModel(c color.Color) color.Color {
+	if c, ok := c.(GrayAlpha); ok {
+		return c
+	}
+
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+
+	// same weights as color.GrayModel
+	y := (19595*uint32(n.R) + 38470*uint32(n.G) + 7471*uint32(n.B) + 1<<15) >> 16
+	return GrayAlpha{Y: uint8(y), A: n.A}
+}
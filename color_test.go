@@ -0,0 +1,54 @@
+package tga
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestColorModels(t *testing.T) {
+	testCases := []struct {
+		model    color.Model
+		input    color.Color
+		expected color.Color
+	}{
+		{model: BGR888Model, input: color.RGBA{R: 1, G: 2, B: 3, A: 255}, expected: BGR888{B: 3, G: 2, R: 1}},
+		{model: BGRA8888Model, input: color.RGBA{R: 1, G: 2, B: 3, A: 255}, expected: BGRA8888{B: 3, G: 2, R: 1, A: 255}},
+		{model: BGRA8888Model, input: color.RGBA{R: 64, G: 32, B: 0, A: 128}, expected: BGRA8888{B: 0, G: 63, R: 127, A: 128}},
+		{model: ARGB1555Model, input: color.RGBA{R: 255, G: 0, B: 255, A: 255}, expected: ARGB1555(0xfc1f)},
+		{model: ARGB1555Model, input: color.RGBA{R: 0, G: 0, B: 0, A: 0}, expected: ARGB1555(0x0000)},
+		{model: ARGB1555Model, input: color.NRGBA{R: 255, G: 255, B: 255, A: 64}, expected: ARGB1555(0x7fff)},
+		{model: GrayAlphaModel, input: color.RGBA{R: 255, G: 255, B: 255, A: 255}, expected: GrayAlpha{Y: 255, A: 255}},
+		{model: GrayAlphaModel, input: color.NRGBA{R: 100, G: 100, B: 100, A: 50}, expected: GrayAlpha{Y: 100, A: 50}},
+		{model: BGR888Model, input: BGR888{B: 9, G: 8, R: 7}, expected: BGR888{B: 9, G: 8, R: 7}},
+	}
+
+	for i, tc := range testCases {
+		got := tc.model.Convert(tc.input)
+
+		if tc.expected != got {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestColorRGBA(t *testing.T) {
+	testCases := []struct {
+		input    color.Color
+		expected color.RGBA64
+	}{
+		{input: BGR888{B: 3, G: 2, R: 1}, expected: color.RGBA64{R: 0x0101, G: 0x0202, B: 0x0303, A: 0xffff}},
+		{input: BGRA8888{B: 0, G: 0, R: 255, A: 0}, expected: color.RGBA64{}},
+		{input: BGRA8888{B: 255, G: 255, R: 255, A: 255}, expected: color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}},
+		{input: ARGB1555(0xfc1f), expected: color.RGBA64{R: 0xffff, G: 0, B: 0xffff, A: 0xffff}},
+		{input: ARGB1555(0x7c1f), expected: color.RGBA64{}},
+		{input: GrayAlpha{Y: 255, A: 255}, expected: color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}},
+	}
+
+	for i, tc := range testCases {
+		got := color.RGBA64Model.Convert(tc.input)
+
+		if tc.expected != got {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
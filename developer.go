@@ -0,0 +1,86 @@
+package tga
+
+import (
+	"fmt"
+	"io"
+)
+
+// DeveloperTag is an entry of the developer directory, pointing to data only
+// the software that wrote it understands.
+type DeveloperTag struct {
+	Tag    uint16
+	Offset uint32 // 4 bytes, from the beginning of the file
+	Size   uint32
+	Data   []byte // only loaded by Read and DecodeWithMetadata
+}
+
+// developerTagLen is the size of a directory entry: tag, offset and size.
+const developerTagLen = 10
+
+// readDeveloperDirectory reads the developer directory of a file of size
+// bytes, leaving the data of the tags empty. readDeveloperData loads it.
+func readDeveloperDirectory(rs io.ReadSeeker, footer Footer, size int64) ([]DeveloperTag, error) {
+	if footer.version() != NewTGA || footer.DeveloperDirectoryOffset == 0 {
+		return nil, nil
+	}
+
+	var count uint16
+
+	err := read(rs, newSection(2, int(footer.DeveloperDirectoryOffset), io.SeekStart), &count)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(footer.DeveloperDirectoryOffset)+2+int64(count)*developerTagLen > size {
+		return nil, fmt.Errorf("%d entries run past the end of the file", count)
+	}
+
+	entries := make([]struct {
+		Tag    uint16
+		Offset uint32
+		Size   uint32
+	}, count)
+
+	err = read(rs, newSection(len(entries)*developerTagLen, int(footer.DeveloperDirectoryOffset)+2, io.SeekStart), entries)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]DeveloperTag, len(entries))
+	for i, e := range entries {
+		tags[i] = DeveloperTag{Tag: e.Tag, Offset: e.Offset, Size: e.Size}
+	}
+
+	return tags, nil
+}
+
+// readDeveloperData loads the data of the tags from a file of size bytes.
+// Tags whose data can't be read are left empty, and the first error is
+// returned.
+func readDeveloperData(rs io.ReadSeeker, size int64, tags []DeveloperTag) error {
+	var first error
+
+	for i, tag := range tags {
+		// the size comes from the file, it is checked before allocating
+		if int64(tag.Offset)+int64(tag.Size) > size {
+			if first == nil {
+				first = fmt.Errorf("tag %d: data runs past the end of the file", tag.Tag)
+			}
+			continue
+		}
+
+		data := make([]byte, tag.Size)
+
+		err := read(rs, newSection(len(data), int(tag.Offset), io.SeekStart), data)
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("tag %d: %v", tag.Tag, err)
+			}
+			continue
+		}
+
+		tags[i].Data = data
+	}
+
+	return first
+}
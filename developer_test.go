@@ -0,0 +1,158 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// newDeveloperTestFile lays out a TGA 2.0 file as header, image data, the
+// data of every tag, the developer directory and footer.
+func newDeveloperTestFile(t *testing.T, header Header, data []byte, tags []DeveloperTag) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	write := func(data any) {
+		if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	write(header)
+	write(data)
+
+	for i := range tags {
+		tags[i].Offset = uint32(buf.Len())
+		tags[i].Size = uint32(len(tags[i].Data))
+		write(tags[i].Data)
+	}
+
+	footer := Footer{Point: '.', DeveloperDirectoryOffset: uint32(buf.Len())}
+	copy(footer.Signature[:], "TRUEVISION-XFILE")
+
+	write(uint16(len(tags)))
+	for _, tag := range tags {
+		write(tag.Tag)
+		write(tag.Offset)
+		write(tag.Size)
+	}
+
+	write(footer)
+
+	return buf.Bytes()
+}
+
+func TestReadDeveloperDirectory(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	testCases := []struct {
+		tags []DeveloperTag
+	}{
+		{tags: []DeveloperTag{}},
+		{tags: []DeveloperTag{{Tag: 1, Data: []byte("layer")}}},
+		{tags: []DeveloperTag{{Tag: 1, Data: []byte("layer")}, {Tag: 0xffff, Data: []byte{}}, {Tag: 7, Data: []byte{1, 2, 3}}}},
+	}
+
+	for i, tc := range testCases {
+		input := bytes.NewReader(newDeveloperTestFile(t, header, []byte{1, 2, 3}, tc.tags))
+
+		got, err := Read(input)
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(tc.tags, got.DeveloperTags) {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.tags, got.DeveloperTags)
+		}
+	}
+}
+
+func TestBrokenOptionalSections(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	// patch returns a file whose field at offset, from the end when
+	// negative, is set to v
+	patch := func(file []byte, offset int, v uint32) []byte {
+		if offset < 0 {
+			offset += len(file)
+		}
+
+		binary.LittleEndian.PutUint32(file[offset:], v)
+		return file
+	}
+
+	tagged := func() []byte {
+		return newDeveloperTestFile(t, header, []byte{1, 2, 3}, []DeveloperTag{{Tag: 1, Data: []byte("layer")}})
+	}
+
+	// the directory follows the header, the pixel and the 5 bytes of the tag
+	const entry = headerLen + 3 + 5 + developerDirectoryHeaderLen
+
+	testCases := []struct {
+		name   string
+		input  []byte
+		broken string // section Read leaves out
+	}{
+		{name: "tag offset past the end", input: patch(tagged(), entry+2, 1<<20), broken: "DeveloperTags"},
+		{name: "huge tag size", input: patch(tagged(), entry+6, 0xffffffff), broken: "DeveloperTags"},
+		{name: "directory past the end", input: patch(tagged(), -footerLen+footerDeveloperOffset, 1<<20), broken: "DeveloperTags"},
+		{name: "extension area past the end", input: patch(newTestFile(t, header, []byte{1, 2, 3}, &ExtensionArea{}, nil, false), -footerLen, 1<<20), broken: "ExtensionArea"},
+	}
+
+	expected := color.RGBA{3, 2, 1, 255}
+
+	for _, tc := range testCases {
+		img, err := Decode(bytes.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("%s: failed to Decode: %v", tc.name, err)
+		}
+
+		if got := img.At(0, 0); got != expected {
+			t.Errorf("%s: Decode: expected %v, but got %v", tc.name, expected, got)
+		}
+
+		_, meta, err := DecodeWithMetadata(bytes.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("%s: failed to DecodeWithMetadata: %v", tc.name, err)
+		}
+
+		for _, tag := range meta.DeveloperTags {
+			if tag.Data != nil {
+				t.Errorf("%s: expected no data for tag %d, but got %q", tc.name, tag.Tag, tag.Data)
+			}
+		}
+
+		file, err := Open(bytes.NewReader(tc.input), int64(len(tc.input)))
+		if err != nil {
+			t.Fatalf("%s: failed to Open: %v", tc.name, err)
+		}
+
+		if got := file.At(0, 0); got != expected {
+			t.Errorf("%s: Open: expected %v, but got %v", tc.name, expected, got)
+		}
+
+		read, err := Read(bytes.NewReader(tc.input))
+
+		broken, ok := err.(SectionsError)
+		if !ok || len(broken) != 1 || broken[0].Name != tc.broken {
+			t.Errorf("%s: expected Read to report %s as broken, but got `%v`", tc.name, tc.broken, err)
+		}
+
+		if !bytes.Equal([]byte{1, 2, 3}, read.Image.Data) {
+			t.Errorf("%s: expected Read to load Image.Data, but got `%v`", tc.name, read.Image.Data)
+		}
+
+		if read.ExtensionArea != nil && tc.broken == "ExtensionArea" {
+			t.Errorf("%s: expected Read to leave the extension area out", tc.name)
+		}
+
+		for _, tag := range read.DeveloperTags {
+			if tag.Data != nil {
+				t.Errorf("%s: expected Read to leave the data of tag %d out, but got %q", tc.name, tag.Tag, tag.Data)
+			}
+		}
+	}
+}
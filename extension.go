@@ -0,0 +1,178 @@
+package tga
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+)
+
+const (
+	extensionAreaLen        = 495
+	colorCorrectionTableLen = 256 * 4 * 2
+)
+
+// ExtensionArea is the TGA 2.0 Extension Area, located through
+// Footer.ExtensionAreaOffset.
+type ExtensionArea struct {
+	ExtensionSize         uint16    // 2 bytes, always 495
+	AuthorName            [41]byte  // 41 bytes, null-terminated
+	AuthorComments        [324]byte // 4 lines of 81 bytes, null-terminated
+	DateTimeStamp         [6]uint16 // month, day, year, hour, minute, second
+	JobName               [41]byte  // 41 bytes, null-terminated
+	JobTime               [3]uint16 // hours, minutes, seconds
+	SoftwareID            [41]byte  // 41 bytes, null-terminated
+	SoftwareVersionNumber uint16    // 2 bytes, version * 100
+	SoftwareVersionLetter byte      // byte
+	KeyColor              uint32    // 4 bytes, A:R:G:B
+	PixelAspectRatio      [2]uint16 // numerator, denominator
+	GammaValue            [2]uint16 // numerator, denominator
+	ColorCorrectionOffset uint32    // 4 bytes, from the beginning of the file
+	PostageStampOffset    uint32    // 4 bytes, from the beginning of the file
+	ScanLineOffset        uint32    // 4 bytes, from the beginning of the file
+	AttributesType        byte      // byte
+}
+
+func (e ExtensionArea) HasColorCorrectionTable() bool {
+	return e.ColorCorrectionOffset != 0
+}
+
+func (e ExtensionArea) HasScanLineTable() bool {
+	return e.ScanLineOffset != 0
+}
+
+// Key returns KeyColor, the background or transparent color of the image.
+func (e ExtensionArea) Key() color.NRGBA {
+	return color.NRGBA{
+		R: uint8(e.KeyColor >> 16),
+		G: uint8(e.KeyColor >> 8),
+		B: uint8(e.KeyColor),
+		A: uint8(e.KeyColor >> 24),
+	}
+}
+
+// Gamma returns the gamma the image was stored with, or 0 when the field is
+// not used.
+func (e ExtensionArea) Gamma() float64 {
+	if e.GammaValue[1] == 0 {
+		return 0
+	}
+
+	return float64(e.GammaValue[0]) / float64(e.GammaValue[1])
+}
+
+// AspectRatio returns the width of a pixel divided by its height, or 0 when
+// the field is not used.
+func (e ExtensionArea) AspectRatio() float64 {
+	if e.PixelAspectRatio[0] == 0 || e.PixelAspectRatio[1] == 0 {
+		return 0
+	}
+
+	return float64(e.PixelAspectRatio[0]) / float64(e.PixelAspectRatio[1])
+}
+
+// Values of ExtensionArea.AttributesType.
+const (
+	NoAlpha              byte = iota // no alpha data included
+	UndefinedAlphaIgnore             // undefined data in the alpha field, can be ignored
+	UndefinedAlphaRetain             // undefined data in the alpha field, should be retained
+	UsefulAlpha                      // useful, non-premultiplied alpha
+	PremultipliedAlpha               // pre-multiplied alpha
+)
+
+type alphaMode int
+
+const (
+	alphaNone          alphaMode = iota // opaque, stored alpha is dropped
+	alphaStraight                       // non-premultiplied alpha
+	alphaPremultiplied                  // premultiplied alpha
+)
+
+// alphaModeOf tells how to treat the alpha of the stored pixels. The
+// attributes type decides when there is an extension area, and the alpha
+// bits of the image descriptor otherwise.
+func alphaModeOf(h Header, ext *ExtensionArea) alphaMode {
+	if h.BitsPerPixel != 16 && h.BitsPerPixel != 32 {
+		return alphaNone
+	}
+
+	if ext == nil {
+		if h.ImageDescriptor.AlphaBits() == 0 {
+			return alphaNone
+		}
+
+		return alphaStraight
+	}
+
+	switch ext.AttributesType {
+	case UndefinedAlphaRetain, UsefulAlpha:
+		return alphaStraight
+	case PremultipliedAlpha:
+		return alphaPremultiplied
+	default:
+		return alphaNone
+	}
+}
+
+// ColorCorrection holds 16 bits per channel, where 0 is black and 65535 is
+// full intensity.
+type ColorCorrection struct {
+	A uint16
+	R uint16
+	G uint16
+	B uint16
+}
+
+type ColorCorrectionTable [256]ColorCorrection
+
+func readExtensionArea(rs io.ReadSeeker, footer Footer) (*ExtensionArea, error) {
+	if footer.version() != NewTGA || footer.ExtensionAreaOffset == 0 {
+		return nil, nil
+	}
+
+	var ext ExtensionArea
+
+	err := read(rs, newSection(extensionAreaLen, int(footer.ExtensionAreaOffset), io.SeekStart), &ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext.ExtensionSize != extensionAreaLen {
+		return nil, fmt.Errorf("unexpected extension area size '%d'", ext.ExtensionSize)
+	}
+
+	return &ext, nil
+}
+
+func readColorCorrectionTable(rs io.ReadSeeker, ext *ExtensionArea) (*ColorCorrectionTable, error) {
+	if ext == nil || !ext.HasColorCorrectionTable() {
+		return nil, nil
+	}
+
+	var table ColorCorrectionTable
+
+	err := read(rs, newSection(colorCorrectionTableLen, int(ext.ColorCorrectionOffset), io.SeekStart), &table)
+	if err != nil {
+		return nil, err
+	}
+
+	return &table, nil
+}
+
+// ScanLineTable holds, for every scan line in the order they are stored, the
+// offset from the beginning of the file to its first byte.
+type ScanLineTable []uint32
+
+func readScanLineTable(rs io.ReadSeeker, header Header, ext *ExtensionArea) (ScanLineTable, error) {
+	if ext == nil || !ext.HasScanLineTable() {
+		return nil, nil
+	}
+
+	table := make(ScanLineTable, header.Height)
+
+	err := read(rs, newSection(len(table)*4, int(ext.ScanLineOffset), io.SeekStart), table)
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
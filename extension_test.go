@@ -0,0 +1,178 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// newTestFile lays out a TGA 2.0 file as header, image data, color
+// correction table, scan line table, extension area and footer. Offsets in
+// ext are filled in, and the scan line table is only written when scanLines
+// is set.
+func newTestFile(t *testing.T, header Header, data []byte, ext *ExtensionArea, table *ColorCorrectionTable, scanLines bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	write := func(data any) {
+		if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	write(header)
+	write(data)
+
+	footer := Footer{Point: '.'}
+	copy(footer.Signature[:], "TRUEVISION-XFILE")
+
+	if ext != nil {
+		if table != nil {
+			ext.ColorCorrectionOffset = uint32(buf.Len())
+			write(table)
+		}
+
+		if scanLines {
+			rowLen := int(header.Width) * header.BytesPerPixel()
+
+			offsets := make(ScanLineTable, header.Height)
+			for i := range offsets {
+				offsets[i] = uint32(headerLen + int(header.IDLength) + i*rowLen)
+			}
+
+			ext.ScanLineOffset = uint32(buf.Len())
+			write(offsets)
+		}
+
+		ext.ExtensionSize = extensionAreaLen
+		footer.ExtensionAreaOffset = uint32(buf.Len())
+		write(ext)
+	}
+
+	write(footer)
+
+	return buf.Bytes()
+}
+
+func TestReadExtensionArea(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	var table ColorCorrectionTable
+	for i := range table {
+		table[i] = ColorCorrection{A: 0xffff, R: uint16(i) * 0x101, G: uint16(i) * 0x101, B: uint16(i) * 0x101}
+	}
+
+	ext := ExtensionArea{
+		SoftwareVersionNumber: 120,
+		SoftwareVersionLetter: 'b',
+		KeyColor:              0xffff00ff,
+		PixelAspectRatio:      [2]uint16{10, 11},
+		GammaValue:            [2]uint16{22, 10},
+		AttributesType:        3,
+	}
+	copy(ext.AuthorName[:], "fesiqueira")
+
+	testCases := []struct {
+		ext   *ExtensionArea
+		table *ColorCorrectionTable
+	}{
+		{ext: nil, table: nil},
+		{ext: &ext, table: nil},
+		{ext: &ext, table: &table},
+	}
+
+	for i, tc := range testCases {
+		input := bytes.NewReader(newTestFile(t, header, []byte{1, 2, 3}, tc.ext, tc.table, false))
+
+		got, err := Read(input)
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(tc.ext, got.ExtensionArea) {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.ext, got.ExtensionArea)
+		}
+
+		if !reflect.DeepEqual(tc.table, got.ColorCorrectionTable) {
+			t.Errorf("test %d: expected color correction table %v, but got %v", i+1, tc.table, got.ColorCorrectionTable)
+		}
+	}
+}
+
+func TestExtensionAreaKey(t *testing.T) {
+	testCases := []struct {
+		keyColor uint32
+		expected color.NRGBA
+	}{
+		{keyColor: 0, expected: color.NRGBA{}},
+		{keyColor: 0xffff00ff, expected: color.NRGBA{R: 255, G: 0, B: 255, A: 255}},
+		{keyColor: 0x80102030, expected: color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0x80}},
+	}
+
+	for i, tc := range testCases {
+		got := ExtensionArea{KeyColor: tc.keyColor}.Key()
+		if got != tc.expected {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestExtensionAreaAspectRatio(t *testing.T) {
+	testCases := []struct {
+		pixelAspectRatio [2]uint16
+		expected         float64
+	}{
+		{pixelAspectRatio: [2]uint16{0, 0}, expected: 0},
+		{pixelAspectRatio: [2]uint16{1, 0}, expected: 0},
+		{pixelAspectRatio: [2]uint16{1, 1}, expected: 1},
+		{pixelAspectRatio: [2]uint16{10, 11}, expected: 10.0 / 11},
+	}
+
+	for i, tc := range testCases {
+		got := ExtensionArea{PixelAspectRatio: tc.pixelAspectRatio}.AspectRatio()
+		if got != tc.expected {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestReadExtensionAreaSize(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	data := newTestFile(t, header, []byte{1, 2, 3}, &ExtensionArea{}, nil, false)
+	data[headerLen+3] = 0xff // corrupt ExtensionSize
+
+	_, err := Read(bytes.NewReader(data))
+	if err == nil {
+		t.Errorf("expected an error for a bad extension area size")
+	}
+}
+
+func TestReadScanLineTable(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 3, BitsPerPixel: 16}
+
+	testCases := []struct {
+		scanLines bool
+		expected  ScanLineTable
+	}{
+		{scanLines: false, expected: nil},
+		{scanLines: true, expected: ScanLineTable{18, 22, 26}},
+	}
+
+	for i, tc := range testCases {
+		data := make([]byte, header.ImageBytes())
+		input := bytes.NewReader(newTestFile(t, header, data, &ExtensionArea{}, nil, tc.scanLines))
+
+		got, err := Read(input)
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(tc.expected, got.ScanLineTable) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got.ScanLineTable)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package tga
+
+import (
+	"image"
+	"image/color"
+)
+
+// BGR is an in-memory image whose Pix holds BGR888 pixels, laid out as
+// Targa 24 image data with a top-left origin.
+type BGR struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewBGR(r image.Rectangle) *BGR {
+	return &BGR{
+		Pix:    make([]uint8, 3*r.Dx()*r.Dy()),
+		Stride: 3 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+func (p *BGR) ColorModel() color.Model { return BGR888Model }
+
+func (p *BGR) Bounds() image.Rectangle { return p.Rect }
+
+func (p *BGR) At(x, y int) color.Color {
+	return p.BGR888At(x, y)
+}
+
+func (p *BGR) BGR888At(x, y int) BGR888 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return BGR888{}
+	}
+
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+3 : i+3]
+	return BGR888{B: s[0], G: s[1], R: s[2]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds
+// to the pixel at (x, y).
+func (p *BGR) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+}
+
+func (p *BGR) Set(x, y int, c color.Color) {
+	p.SetBGR888(x, y, BGR888Model.Convert(c).(BGR888))
+}
+
+func (p *BGR) SetBGR888(x, y int, c BGR888) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+3 : i+3]
+	s[0], s[1], s[2] = c.B, c.G, c.R
+}
+
+// SubImage returns an image representing the portion of p visible through
+// r. The returned value shares pixels with the original image.
+func (p *BGR) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &BGR{}
+	}
+
+	return &BGR{
+		Pix:    p.Pix[p.PixOffset(r.Min.X, r.Min.Y):],
+		Stride: p.Stride,
+		Rect:   r,
+	}
+}
+
+func (p *BGR) Opaque() bool { return true }
+
+// BGRA is an in-memory image whose Pix holds non-premultiplied BGRA8888
+// pixels, laid out as Targa 32 image data with a top-left origin.
+type BGRA struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewBGRA(r image.Rectangle) *BGRA {
+	return &BGRA{
+		Pix:    make([]uint8, 4*r.Dx()*r.Dy()),
+		Stride: 4 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+func (p *BGRA) ColorModel() color.Model { return BGRA8888Model }
+
+func (p *BGRA) Bounds() image.Rectangle { return p.Rect }
+
+func (p *BGRA) At(x, y int) color.Color {
+	return p.BGRA8888At(x, y)
+}
+
+func (p *BGRA) BGRA8888At(x, y int) BGRA8888 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return BGRA8888{}
+	}
+
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	return BGRA8888{B: s[0], G: s[1], R: s[2], A: s[3]}
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds
+// to the pixel at (x, y).
+func (p *BGRA) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *BGRA) Set(x, y int, c color.Color) {
+	p.SetBGRA8888(x, y, BGRA8888Model.Convert(c).(BGRA8888))
+}
+
+func (p *BGRA) SetBGRA8888(x, y int, c BGRA8888) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+
+	i := p.PixOffset(x, y)
+	s := p.Pix[i : i+4 : i+4]
+	s[0], s[1], s[2], s[3] = c.B, c.G, c.R, c.A
+}
+
+// SubImage returns an image representing the portion of p visible through
+// r. The returned value shares pixels with the original image.
+func (p *BGRA) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &BGRA{}
+	}
+
+	return &BGRA{
+		Pix:    p.Pix[p.PixOffset(r.Min.X, r.Min.Y):],
+		Stride: p.Stride,
+		Rect:   r,
+	}
+}
+
+func (p *BGRA) Opaque() bool {
+	if p.Rect.Empty() {
+		return true
+	}
+
+	i0, i1 := 3, p.Rect.Dx()*4
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for i := i0; i < i1; i += 4 {
+			if p.Pix[i] != 0xff {
+				return false
+			}
+		}
+		i0 += p.Stride
+		i1 += p.Stride
+	}
+
+	return true
+}
+
+// RGB555 is an in-memory image whose Pix holds little-endian ARGB1555
+// pixels, laid out as Targa 16 image data with a top-left origin.
+type RGB555 struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+}
+
+func NewRGB555(r image.Rectangle) *RGB555 {
+	return &RGB555{
+		Pix:    make([]uint8, 2*r.Dx()*r.Dy()),
+		Stride: 2 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+func (p *RGB555) ColorModel() color.Model { return ARGB1555Model }
+
+func (p *RGB555) Bounds() image.Rectangle { return p.Rect }
+
+func (p *RGB555) At(x, y int) color.Color {
+	return p.ARGB1555At(x, y)
+}
+
+func (p *RGB555) ARGB1555At(x, y int) ARGB1555 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return 0
+	}
+
+	i := p.PixOffset(x, y)
+	return ARGB1555(p.Pix[i]) | ARGB1555(p.Pix[i+1])<<8
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds
+// to the pixel at (x, y).
+func (p *RGB555) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+func (p *RGB555) Set(x, y int, c color.Color) {
+	p.SetARGB1555(x, y, ARGB1555Model.Convert(c).(ARGB1555))
+}
+
+func (p *RGB555) SetARGB1555(x, y int, c ARGB1555) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+
+	i := p.PixOffset(x, y)
+	p.Pix[i+0] = uint8(c)
+	p.Pix[i+1] = uint8(c >> 8)
+}
+
+// SubImage returns an image representing the portion of p visible through
+// r. The returned value shares pixels with the original image.
+func (p *RGB555) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &RGB555{}
+	}
+
+	return &RGB555{
+		Pix:    p.Pix[p.PixOffset(r.Min.X, r.Min.Y):],
+		Stride: p.Stride,
+		Rect:   r,
+	}
+}
+
+func (p *RGB555) Opaque() bool {
+	if p.Rect.Empty() {
+		return true
+	}
+
+	i0, i1 := 1, p.Rect.Dx()*2
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for i := i0; i < i1; i += 2 {
+			if p.Pix[i]&0x80 == 0 {
+				return false
+			}
+		}
+		i0 += p.Stride
+		i1 += p.Stride
+	}
+
+	return true
+}
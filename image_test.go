@@ -0,0 +1,62 @@
+package tga
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTypedImages(t *testing.T) {
+	rect := image.Rect(0, 0, 2, 2)
+
+	testCases := []struct {
+		img interface {
+			image.Image
+			Set(x, y int, c color.Color)
+			SubImage(r image.Rectangle) image.Image
+			Opaque() bool
+		}
+		expected []byte
+	}{
+		{img: NewBGR(rect), expected: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 2, 1}},
+		{img: NewBGRA(rect), expected: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 2, 1, 255}},
+		{img: NewRGB555(rect), expected: []byte{0, 0, 0, 0, 0, 0, 0x1f, 0xfc}},
+	}
+
+	for i, tc := range testCases {
+		c := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+		if _, ok := tc.img.(*RGB555); ok {
+			c = color.RGBA{R: 255, G: 0, B: 255, A: 255}
+		}
+
+		tc.img.Set(1, 1, c)
+
+		var pix []byte
+		switch img := tc.img.(type) {
+		case *BGR:
+			pix = img.Pix
+		case *BGRA:
+			pix = img.Pix
+		case *RGB555:
+			pix = img.Pix
+		}
+
+		if !bytes.Equal(tc.expected, pix) {
+			t.Errorf("test %d: expected Pix `%v`, but got `%v`", i+1, tc.expected, pix)
+		}
+
+		if got := color.RGBAModel.Convert(tc.img.At(1, 1)); got != c {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, c, got)
+		}
+
+		sub := tc.img.SubImage(image.Rect(1, 1, 5, 5))
+		if sub.Bounds() != image.Rect(1, 1, 2, 2) {
+			t.Errorf("test %d: expected sub image bounds `%v`, but got `%v`", i+1, image.Rect(1, 1, 2, 2), sub.Bounds())
+		}
+
+		if got := color.RGBAModel.Convert(sub.At(1, 1)); got != c {
+			t.Errorf("test %d: sub image: expected `%v`, but got `%v`", i+1, c, got)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package tga
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Section is a range of bytes of a file, as listed by File.Layout.
+type Section struct {
+	Name       string
+	Offset     int64
+	Length     int64
+	Gap        bool // bytes no section accounts for
+	OutOfRange bool // runs past the end of the file
+}
+
+func (s Section) End() int64 {
+	return s.Offset + s.Length
+}
+
+type namedSection struct {
+	name string
+	sectionConfig
+}
+
+// unknownLength marks sections whose length can't be told without decoding
+// them. They are taken to run up to the next section.
+const unknownLength = -1
+
+// Layout returns the sections of f ordered by offset, with the gaps between
+// them. Run-length encoded pixel data is taken to run up to the next
+// section. Files not from Read, Open or ReadLayout are assumed to end with
+// their last section.
+func (f File) Layout() []Section {
+	var configs []namedSection
+
+	add := func(name string, config sectionConfig) {
+		configs = append(configs, namedSection{name: name, sectionConfig: config})
+	}
+
+	add("header", headerSection)
+
+	offset := int(headerSection.length)
+
+	if f.Header.HasImageIDField() {
+		add("image ID", newSection(int(f.Header.IDLength), offset, io.SeekStart))
+		offset += int(f.Header.IDLength)
+	}
+
+	if f.Header.HasColorMap() {
+		n := f.Header.colorMapBytes()
+		add("color map", newSection(n, offset, io.SeekStart))
+		offset += n
+	}
+
+	switch f.Header.ImageType {
+	case NoImage:
+	case RunLengthEncodedColorMappedImage, RunLengthEncodedRGBImage, RunLengthEncodedGrayscaleImage:
+		add("pixel data", newSection(unknownLength, offset, io.SeekStart))
+	default:
+		add("pixel data", newSection(f.Header.ImageBytes(), offset, io.SeekStart))
+	}
+
+	if f.Version() == NewTGA {
+		for _, tag := range f.DeveloperTags {
+			add(fmt.Sprintf("developer tag %d", tag.Tag), newSection(int(tag.Size), int(tag.Offset), io.SeekStart))
+		}
+
+		if f.Footer.DeveloperDirectoryOffset != 0 {
+			n := developerDirectoryHeaderLen + len(f.DeveloperTags)*developerTagLen
+			add("developer directory", newSection(n, int(f.Footer.DeveloperDirectoryOffset), io.SeekStart))
+		}
+
+		// the offset is listed even when the extension area can't be read,
+		// only what it points to is then unknown
+		if f.Footer.ExtensionAreaOffset != 0 {
+			add("extension area", newSection(extensionAreaLen, int(f.Footer.ExtensionAreaOffset), io.SeekStart))
+		}
+
+		if ext := f.ExtensionArea; ext != nil {
+
+			if ext.PostageStampOffset != 0 {
+				n := unknownLength
+				if f.stampSize != [2]byte{} {
+					n = postageStampHeaderLen + int(f.stampSize[0])*int(f.stampSize[1])*f.Header.BytesPerPixel()
+				}
+
+				add("postage stamp", newSection(n, int(ext.PostageStampOffset), io.SeekStart))
+			}
+
+			if ext.HasColorCorrectionTable() {
+				add("color correction table", newSection(colorCorrectionTableLen, int(ext.ColorCorrectionOffset), io.SeekStart))
+			}
+
+			if ext.HasScanLineTable() {
+				add("scan line table", newSection(int(f.Header.Height)*4, int(ext.ScanLineOffset), io.SeekStart))
+			}
+		}
+
+		add("footer", footerSection)
+	}
+
+	// offsets from the end need the size of the file
+	size := f.size
+	if size == 0 {
+		for _, c := range configs {
+			if c.whence == io.SeekStart && c.offset+c.length > size {
+				size = c.offset + c.length
+			}
+		}
+
+		if f.Version() == NewTGA {
+			size += footerLen
+		}
+	}
+
+	sections := make([]Section, len(configs))
+	for i, c := range configs {
+		sections[i] = Section{Name: c.name, Offset: c.offset, Length: c.length}
+		if c.whence == io.SeekEnd {
+			sections[i].Offset += size
+		}
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].Offset < sections[j].Offset
+	})
+
+	for i := range sections {
+		if sections[i].Length != unknownLength {
+			continue
+		}
+
+		next := size
+		if i+1 < len(sections) {
+			next = sections[i+1].Offset
+		}
+
+		sections[i].Length = next - sections[i].Offset
+		if sections[i].Length < 0 {
+			sections[i].Length = 0
+		}
+	}
+
+	for i := range sections {
+		sections[i].OutOfRange = sections[i].End() > size
+	}
+
+	// bytes between sections, and after the last one
+	var layout []Section
+
+	end := int64(0)
+	for _, s := range sections {
+		// gaps stop at the end of the file
+		start := s.Offset
+		if start > size {
+			start = size
+		}
+
+		if start > end {
+			layout = append(layout, Section{Name: "gap", Offset: end, Length: start - end, Gap: true})
+		}
+
+		layout = append(layout, s)
+
+		if s.End() > end {
+			end = s.End()
+		}
+	}
+
+	if end < size {
+		layout = append(layout, Section{Name: "gap", Offset: end, Length: size - end, Gap: true})
+	}
+
+	return layout
+}
+
+// ReadLayout returns the layout of the file in rs like File.Layout, only
+// reading the header, the footer and the sections they point to. Unlike Read,
+// broken sections and sections running past the end of the file don't make
+// it fail: they are listed where the file records them.
+func ReadLayout(rs io.ReadSeeker) ([]Section, error) {
+	var file File
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("tga.ReadLayout: failed to seek file: %v", err)
+	}
+
+	file.size = size
+
+	err = read(rs, headerSection, &file.Header)
+	if err != nil {
+		return nil, fmt.Errorf("tga.ReadLayout: failed to read binary data into Header: %v", err)
+	}
+
+	// files too short for a footer can only be original TGA files
+	if size >= footerLen {
+		err = read(rs, footerSection, &file.Footer)
+		if err != nil {
+			return nil, fmt.Errorf("tga.ReadLayout: failed to read binary data into Footer: %v", err)
+		}
+	}
+
+	// what can't be read is left out, its offset is still listed
+	file.ExtensionArea, _ = readExtensionArea(rs, file.Footer)
+	file.DeveloperTags, _ = readDeveloperDirectory(rs, file.Footer, size)
+	file.stampSize = readStampSize(rs, file.ExtensionArea)
+
+	return file.Layout(), nil
+}
+
+// readStampSize returns the width and height of the postage stamp, left
+// unknown when the extension area has none or its offset is broken.
+func readStampSize(rs io.ReadSeeker, ext *ExtensionArea) [2]byte {
+	var size [2]byte
+
+	if ext != nil && ext.PostageStampOffset != 0 {
+		if read(rs, newSection(len(size), int(ext.PostageStampOffset), io.SeekStart), size[:]) != nil {
+			return [2]byte{}
+		}
+	}
+
+	return size
+}
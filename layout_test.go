@@ -0,0 +1,128 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, IDLength: 3, Width: 2, Height: 1, BitsPerPixel: 24}
+
+	var table ColorCorrectionTable
+
+	withExt := newTestFile(t, header, []byte("tga\x01\x02\x03\x04\x05\x06"), &ExtensionArea{}, &table, true)
+
+	withTags := newDeveloperTestFile(t, Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}, []byte{1, 2, 3}, []DeveloperTag{{Tag: 1, Data: []byte("tag")}})
+
+	flag, err := os.ReadFile("./testdata/flag_t16.tga")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+
+	testCases := []struct {
+		data     []byte
+		expected []Section
+	}{
+		{
+			data: withExt,
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "image ID", Offset: 18, Length: 3},
+				{Name: "pixel data", Offset: 21, Length: 6},
+				{Name: "color correction table", Offset: 27, Length: 2048},
+				{Name: "scan line table", Offset: 2075, Length: 4},
+				{Name: "extension area", Offset: 2079, Length: 495},
+				{Name: "footer", Offset: 2574, Length: 26},
+			},
+		},
+		{
+			data: withTags,
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "pixel data", Offset: 18, Length: 3},
+				{Name: "developer tag 1", Offset: 21, Length: 3},
+				{Name: "developer directory", Offset: 24, Length: 12},
+				{Name: "footer", Offset: 36, Length: 26},
+			},
+		},
+		{
+			data: append(append([]byte{}, withTags[:len(withTags)-footerLen]...), append([]byte{0, 0}, withTags[len(withTags)-footerLen:]...)...),
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "pixel data", Offset: 18, Length: 3},
+				{Name: "developer tag 1", Offset: 21, Length: 3},
+				{Name: "developer directory", Offset: 24, Length: 12},
+				{Name: "gap", Offset: 36, Length: 2, Gap: true},
+				{Name: "footer", Offset: 38, Length: 26},
+			},
+		},
+		{
+			data: flag,
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "pixel data", Offset: 18, Length: 30752},
+				{Name: "gap", Offset: 30770, Length: 4096, Gap: true},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		file, err := Read(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		got := file.Layout()
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestReadLayoutOutOfRange(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 1, BitsPerPixel: 24}
+
+	brokenExt := newTestFile(t, header, []byte{1, 2, 3, 4, 5, 6}, &ExtensionArea{}, nil, false)
+	binary.LittleEndian.PutUint32(brokenExt[len(brokenExt)-footerLen:], 1000)
+
+	// the footer-less file ends in the middle of the pixel data
+	truncated := newTestFile(t, Header{ImageType: UncompressedRGBImage, Width: 10, Height: 1, BitsPerPixel: 24}, make([]byte, 12), nil, nil, false)
+	truncated = truncated[:len(truncated)-footerLen]
+
+	testCases := []struct {
+		data     []byte
+		expected []Section
+	}{
+		{
+			data: brokenExt,
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "pixel data", Offset: 18, Length: 6},
+				{Name: "gap", Offset: 24, Length: 495, Gap: true},
+				{Name: "footer", Offset: 519, Length: 26},
+				{Name: "extension area", Offset: 1000, Length: 495, OutOfRange: true},
+			},
+		},
+		{
+			data: truncated,
+			expected: []Section{
+				{Name: "header", Offset: 0, Length: 18},
+				{Name: "pixel data", Offset: 18, Length: 30, OutOfRange: true},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		got, err := ReadLayout(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package tga
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type Severity int
+
+const (
+	Info    Severity = iota // unusual, but within the specification
+	Warning                 // some decoders may misread the file
+	Error                   // the file breaks the specification
+)
+
+func (s Severity) String() string {
+	return [...]string{"Info", "Warning", "Error"}[s]
+}
+
+// Diagnostic is a problem found by Lint, at Offset bytes from the beginning
+// of the file.
+type Diagnostic struct {
+	Severity Severity
+	Offset   int64
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at %d: %s", d.Severity, d.Offset, d.Message)
+}
+
+// offsets of the fields Lint points to
+const (
+	imageDescriptorOffset       = 17
+	footerDeveloperOffset       = 4
+	extColorCorrectionOffset    = 482
+	extPostageStampOffset       = 486
+	extScanLineOffset           = 490
+	postageStampHeaderLen       = 2
+	developerDirectoryHeaderLen = 2
+)
+
+// Lint inspects the file in rs and reports, ordered by offset, what breaks
+// the specification or may trip decoders up. Failures to read the file are
+// reported as diagnostics too.
+func Lint(rs io.ReadSeeker) []Diagnostic {
+	l := linter{rs: rs}
+	l.lint()
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		return l.diagnostics[i].Offset < l.diagnostics[j].Offset
+	})
+
+	return l.diagnostics
+}
+
+type linter struct {
+	rs          io.ReadSeeker
+	size        int64
+	limit       int64 // where sections must end, the footer or EOF
+	end         int64 // end of the furthest section found
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(severity Severity, offset int64, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Severity: severity, Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+// section records a section of n bytes at offset, telling whether it fits in
+// the file. Sections that don't are reported at from, where the field
+// pointing to them is.
+func (l *linter) section(name string, offset, n, from int64) bool {
+	if offset+n > l.limit {
+		l.report(Error, from, "%s at %d runs past the end of the file", name, offset)
+		return false
+	}
+
+	if offset+n > l.end {
+		l.end = offset + n
+	}
+
+	return true
+}
+
+func (l *linter) lint() {
+	var err error
+
+	l.size, err = l.rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		l.report(Error, 0, "failed to seek file: %v", err)
+		return
+	}
+
+	if l.size < headerLen {
+		l.report(Error, 0, "file is %d bytes long, too short for a header", l.size)
+		return
+	}
+
+	var header Header
+
+	err = read(l.rs, headerSection, &header)
+	if err != nil {
+		l.report(Error, 0, "failed to read header: %v", err)
+		return
+	}
+
+	var footer Footer
+
+	l.limit = l.size
+	if l.size >= headerLen+footerLen {
+		err = read(l.rs, footerSection, &footer)
+		if err != nil {
+			l.report(Error, l.size-footerLen, "failed to read footer: %v", err)
+			return
+		}
+
+		if footer.version() == NewTGA {
+			l.limit = l.size - footerLen
+		}
+	}
+
+	if err := header.Validate(); err != nil {
+		l.report(Error, 0, "%v", err)
+	}
+
+	l.lintDescriptor(header, footer.version())
+
+	if !l.lintImage(header) {
+		return
+	}
+
+	if footer.version() == NewTGA {
+		l.lintExtensionArea(header, footer)
+		l.lintDeveloperDirectory(footer)
+	}
+
+	if l.end < l.limit {
+		l.report(Warning, l.end, "%d bytes of trailing data after the last section", l.limit-l.end)
+	}
+}
+
+func (l *linter) lintDescriptor(h Header, version Version) {
+	id := h.ImageDescriptor
+
+	if h.BitsPerPixel == 32 && h.ImageType != NoImage && id.AlphaBits() == 0 {
+		l.report(Warning, imageDescriptorOffset, "32-bit image reports 0 alpha bits")
+	}
+
+	switch {
+	case id.Interleave() == NonInterleaved || id.Interleave() == ReservedInterleave:
+	case version == NewTGA:
+		l.report(Warning, imageDescriptorOffset, "bits 6-7 of the image descriptor must be clear in TGA 2.0")
+	default:
+		l.report(Info, imageDescriptorOffset, "scan lines are stored %s", id.Interleave())
+	}
+}
+
+// lintImage checks the image ID, color map and pixel data, telling whether
+// they fit in the file.
+func (l *linter) lintImage(h Header) bool {
+	offset := int64(headerLen)
+	l.end = offset
+
+	if !l.section("image ID", offset, int64(h.IDLength), 0) {
+		return false
+	}
+
+	id := make([]byte, h.IDLength)
+
+	err := read(l.rs, newSection(len(id), int(offset), io.SeekStart), id)
+	if err != nil {
+		l.report(Error, offset, "failed to read image ID: %v", err)
+		return false
+	}
+
+	if i := bytes.IndexByte(id, 0); i >= 0 && len(bytes.Trim(id[i:], "\x00")) > 0 {
+		l.report(Info, offset+int64(i), "image ID text ends after %d of %d bytes", i, len(id))
+	}
+
+	offset += int64(h.IDLength)
+
+	colorMapped := h.ImageType == UncompressedColorMappedImage || h.ImageType == RunLengthEncodedColorMappedImage
+	colorMapBytes := int64(h.colorMapBytes())
+
+	if h.HasColorMap() {
+		if !l.section("color map", offset, colorMapBytes, 0) {
+			return false
+		}
+
+		if !colorMapped {
+			l.report(Warning, offset, "color map of %d entries is unused by image type '%d'", h.ColorMapLength, h.ImageType)
+		}
+
+		offset += colorMapBytes
+	}
+
+	pixels := int64(h.Width) * int64(h.Height)
+	bytesPerPixel := int64(h.BytesPerPixel())
+
+	var used []bool
+	if colorMapped && h.HasColorMap() {
+		used = make([]bool, h.ColorMapLength)
+	}
+
+	outOfRange := int64(-1)
+	index := func(pixel []byte) {
+		i := int(pixel[0])
+		if len(pixel) > 1 {
+			i |= int(pixel[1]) << 8
+		}
+
+		i -= int(h.ColorMapOrigin)
+		if i < 0 || i >= len(used) {
+			if outOfRange < 0 {
+				outOfRange = int64(i + int(h.ColorMapOrigin))
+			}
+			return
+		}
+
+		used[i] = true
+	}
+
+	switch h.ImageType {
+	case NoImage:
+	case RunLengthEncodedColorMappedImage, RunLengthEncodedRGBImage, RunLengthEncodedGrayscaleImage:
+		var fn func([]byte)
+		if used != nil {
+			fn = index
+		}
+
+		end, ok := l.lintPackets(h, offset, fn)
+		if !ok {
+			return false
+		}
+
+		l.section("pixel data", offset, end-offset, offset)
+	default:
+		n := pixels * bytesPerPixel
+
+		if h.IDLength > 0 && l.limit+int64(h.IDLength) == offset+n {
+			l.report(Error, 0, "ID length is %d, but the pixel data only fits without an image ID", h.IDLength)
+		}
+
+		if !l.section("pixel data", offset, n, offset) {
+			return false
+		}
+
+		if used != nil && bytesPerPixel > 0 {
+			data := make([]byte, n)
+
+			err := read(l.rs, newSection(len(data), int(offset), io.SeekStart), data)
+			if err != nil {
+				l.report(Error, offset, "failed to read pixel data: %v", err)
+				return false
+			}
+
+			for i := int64(0); i < n; i += bytesPerPixel {
+				index(data[i : i+bytesPerPixel])
+			}
+		}
+	}
+
+	if outOfRange >= 0 {
+		l.report(Error, offset, "color map index %d is out of the color map range", outOfRange)
+	}
+
+	unused := 0
+	for _, u := range used {
+		if !u {
+			unused++
+		}
+	}
+
+	if unused > 0 {
+		l.report(Info, headerLen+int64(h.IDLength), "%d of %d color map entries are unused", unused, len(used))
+	}
+
+	return true
+}
+
+// lintPackets walks the run-length encoded pixel data at offset, passing
+// every pixel to fn when it is set, and returns where the packets end.
+func (l *linter) lintPackets(h Header, offset int64, fn func([]byte)) (int64, bool) {
+	data := make([]byte, l.limit-offset)
+
+	err := read(l.rs, newSection(len(data), int(offset), io.SeekStart), data)
+	if err != nil {
+		l.report(Error, offset, "failed to read pixel data: %v", err)
+		return 0, false
+	}
+
+	width, total := int(h.Width), int(h.Width)*int(h.Height)
+	bytesPerPixel := h.BytesPerPixel()
+
+	crossing, first := 0, int64(0)
+
+	i := 0
+	for n := 0; n < total; {
+		if i >= len(data) {
+			l.report(Error, offset+int64(i), "run-length encoded data ends after %d of %d pixels", n, total)
+			return 0, false
+		}
+
+		count := int(data[i]&0x7f) + 1
+		raw := data[i]&0x80 == 0
+
+		size := bytesPerPixel
+		if raw {
+			size = count * bytesPerPixel
+		}
+
+		if i+1+size > len(data) {
+			l.report(Error, offset+int64(i), "run-length encoded data ends after %d of %d pixels", n, total)
+			return 0, false
+		}
+
+		if n+count > total {
+			l.report(Error, offset+int64(i), "packet of %d pixels runs past the last pixel", count)
+		} else if width > 0 && n/width != (n+count-1)/width {
+			if crossing == 0 {
+				first = offset + int64(i)
+			}
+			crossing++
+		}
+
+		if fn != nil {
+			for k := 0; k < count; k++ {
+				if raw {
+					fn(data[i+1+k*bytesPerPixel:][:bytesPerPixel])
+				} else {
+					fn(data[i+1:][:bytesPerPixel])
+				}
+			}
+		}
+
+		n += count
+		i += 1 + size
+	}
+
+	if crossing > 0 {
+		l.report(Warning, first, "%d run-length encoded packets cross scan lines, which TGA 2.0 forbids", crossing)
+	}
+
+	return offset + int64(i), true
+}
+
+func (l *linter) lintExtensionArea(h Header, footer Footer) {
+	if footer.ExtensionAreaOffset == 0 {
+		return
+	}
+
+	offset := int64(footer.ExtensionAreaOffset)
+	if !l.section("extension area", offset, extensionAreaLen, l.size-footerLen) {
+		return
+	}
+
+	var ext ExtensionArea
+
+	err := read(l.rs, newSection(extensionAreaLen, int(offset), io.SeekStart), &ext)
+	if err != nil {
+		l.report(Error, offset, "failed to read extension area: %v", err)
+		return
+	}
+
+	if ext.ExtensionSize != extensionAreaLen {
+		l.report(Error, offset, "extension area size is %d instead of %d", ext.ExtensionSize, extensionAreaLen)
+		return
+	}
+
+	if ext.HasColorCorrectionTable() {
+		l.section("color correction table", int64(ext.ColorCorrectionOffset), colorCorrectionTableLen, offset+extColorCorrectionOffset)
+	}
+
+	if ext.PostageStampOffset != 0 {
+		stamp := int64(ext.PostageStampOffset)
+
+		var size [2]byte
+		if l.section("postage stamp", stamp, postageStampHeaderLen, offset+extPostageStampOffset) {
+			err := read(l.rs, newSection(len(size), int(stamp), io.SeekStart), size[:])
+			if err != nil {
+				l.report(Error, stamp, "failed to read postage stamp: %v", err)
+			} else {
+				n := int64(size[0]) * int64(size[1]) * int64(h.BytesPerPixel())
+				l.section("postage stamp", stamp, postageStampHeaderLen+n, offset+extPostageStampOffset)
+			}
+		}
+	}
+
+	if ext.HasScanLineTable() {
+		l.section("scan line table", int64(ext.ScanLineOffset), int64(h.Height)*4, offset+extScanLineOffset)
+	}
+}
+
+func (l *linter) lintDeveloperDirectory(footer Footer) {
+	if footer.DeveloperDirectoryOffset == 0 {
+		return
+	}
+
+	offset := int64(footer.DeveloperDirectoryOffset)
+	if !l.section("developer directory", offset, developerDirectoryHeaderLen, l.size-footerLen+footerDeveloperOffset) {
+		return
+	}
+
+	var count uint16
+
+	err := read(l.rs, newSection(developerDirectoryHeaderLen, int(offset), io.SeekStart), &count)
+	if err != nil {
+		l.report(Error, offset, "failed to read developer directory: %v", err)
+		return
+	}
+
+	if !l.section("developer directory", offset, developerDirectoryHeaderLen+int64(count)*developerTagLen, l.size-footerLen+footerDeveloperOffset) {
+		return
+	}
+
+	tags, err := readDeveloperDirectory(l.rs, footer, l.size)
+	if err != nil {
+		l.report(Error, offset, "failed to read developer directory: %v", err)
+		return
+	}
+
+	for i, tag := range tags {
+		l.section(fmt.Sprintf("developer tag %d", tag.Tag), int64(tag.Offset), int64(tag.Size), offset+developerDirectoryHeaderLen+int64(i)*developerTagLen)
+	}
+}
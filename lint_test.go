@@ -0,0 +1,93 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	raw := func(parts ...any) []byte {
+		var buf bytes.Buffer
+		for _, part := range parts {
+			if err := binary.Write(&buf, binary.LittleEndian, part); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+		}
+		return buf.Bytes()
+	}
+
+	rgb := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32}
+	pixels := make([]byte, 12)
+
+	clean := newTestFile(t, rgb, pixels, &ExtensionArea{}, nil, false)
+
+	extPastEOF := newTestFile(t, rgb, pixels, &ExtensionArea{}, nil, false)
+	binary.LittleEndian.PutUint32(extPastEOF[len(extPastEOF)-footerLen:], 1<<20)
+
+	scanLinesPastEOF := newTestFile(t, rgb, pixels, &ExtensionArea{}, nil, true)
+	binary.LittleEndian.PutUint32(scanLinesPastEOF[len(scanLinesPastEOF)-footerLen-extensionAreaLen+extScanLineOffset:], 1<<20)
+
+	noAlpha := rgb
+	noAlpha.BitsPerPixel = 32
+
+	interleaved := rgb
+	interleaved.ImageDescriptor |= 64
+
+	rle := rgb
+	rle.ImageType = RunLengthEncodedRGBImage
+
+	withID := rgb
+	withID.IDLength = 4
+
+	colorMapped := Header{
+		ImageType:      UncompressedColorMappedImage,
+		ColorMapType:   1,
+		ColorMapLength: 4,
+		ColorMapDepth:  Targa24,
+		Width:          2,
+		Height:         2,
+		BitsPerPixel:   8,
+	}
+
+	type diagnostic struct {
+		severity Severity
+		offset   int64
+	}
+
+	testCases := []struct {
+		data     []byte
+		expected []diagnostic
+	}{
+		{data: clean, expected: nil},
+		{data: raw(rgb, pixels), expected: nil},
+		{data: raw(rgb)[:10], expected: []diagnostic{{Error, 0}}},
+		{data: raw(rgb, pixels, []byte{1, 2, 3}), expected: []diagnostic{{Warning, 30}}},
+		{data: raw(noAlpha, make([]byte, 16)), expected: []diagnostic{{Warning, 17}}},
+		{data: newTestFile(t, interleaved, pixels, nil, nil, false), expected: []diagnostic{{Warning, 17}}},
+		{data: raw(interleaved, pixels), expected: []diagnostic{{Info, 17}}},
+		{data: extPastEOF, expected: []diagnostic{{Warning, 30}, {Error, int64(len(extPastEOF) - footerLen)}}},
+		{data: scanLinesPastEOF, expected: []diagnostic{{Error, int64(len(scanLinesPastEOF) - footerLen - extensionAreaLen + extScanLineOffset)}}},
+		{data: raw(rle, []byte{0x83, 1, 2, 3}), expected: []diagnostic{{Warning, 18}}},
+		{data: raw(rle, []byte{0x81, 1, 2, 3, 0x81, 4, 5, 6}), expected: nil},
+		{data: raw(rle, []byte{0x81, 1, 2, 3}), expected: []diagnostic{{Error, 22}}},
+		{data: raw(withID, pixels), expected: []diagnostic{{Error, 0}, {Error, 22}}},
+		{data: raw(withID, []byte("tga\x00"), pixels), expected: nil},
+		{data: raw(withID, []byte("t\x00ga"), pixels), expected: []diagnostic{{Info, 19}}},
+		{data: raw(colorMapped, make([]byte, 12), []byte{0, 1, 1, 0}), expected: []diagnostic{{Info, 18}}},
+		{data: raw(colorMapped, make([]byte, 12), []byte{0, 1, 2, 3}), expected: nil},
+		{data: raw(colorMapped, make([]byte, 12), []byte{0, 1, 2, 9}), expected: []diagnostic{{Info, 18}, {Error, 30}}},
+	}
+
+	for i, tc := range testCases {
+		var got []diagnostic
+		for _, d := range Lint(bytes.NewReader(tc.data)) {
+			got = append(got, diagnostic{d.Severity, d.Offset})
+		}
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.expected, Lint(bytes.NewReader(tc.data)))
+		}
+	}
+}
@@ -2,168 +2,740 @@ package tga
 
 import (
 	"bytes"
-	"encoding/binary"
+	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
+	"math"
+	"sync"
 )
 
 type decoder struct {
-	rs     io.ReadSeeker
-	header Header
-	image  Image
-	footer Footer
+	ctx    context.Context
+	rs     io.ReadSeeker // where rows are read from when file doesn't hold them
+	buf    bytes.Buffer  // holds the input when it can't seek
+	br     bytes.Reader
+	opts   DecodeOptions
+	file   File
+	rows   []byte // stored rows read from rs
+	packed []byte // run-length encoded rows read from rs
+	cct    *ColorCorrectionTable
+	alpha  alphaMode
+	key    *[4]byte // RGBA of the key color, as stored pixels decode it
 }
 
-const (
-	headerLen = 18
-	footerLen = 26
-)
+type DecodeOptions struct {
+	// ColorCorrection applies the Extension Area color correction table, when
+	// the file has one, and makes the decoder return an *image.NRGBA64.
+	// DecodeInto fails when it is set.
+	ColorCorrection bool
+
+	// Gamma is the gamma the returned pixels are encoded with: 1 linearizes
+	// them and 2.2 approximates sRGB. Pixels are left untouched when it is 0
+	// or when the file doesn't specify a gamma value.
+	Gamma float64
+
+	// Region restricts decoding to the given rectangle, in display
+	// coordinates. The returned image has the region as its bounds.
+	Region image.Rectangle
+
+	// ScreenOrigin places the image at Header.Origin: the returned bounds
+	// start at XOrigin and YOrigin instead of (0, 0), and so does Region.
+	ScreenOrigin bool
+
+	// Concurrency is the number of goroutines converting rows of pixels.
+	// Values below 2 decode serially.
+	Concurrency int
+
+	// Progress, when set, is called after every decoded row with the number
+	// of rows decoded so far. Calls never overlap, even with Concurrency.
+	Progress func(rows, total int)
+
+	// PixelAspect, when set, resamples images whose extension area pixel
+	// aspect ratio isn't 1:1 to square pixels with the given filter. Images
+	// are stretched along one axis, never shrunk, and decoding fails for
+	// ratios beyond 16:1 or 1:16.
+	PixelAspect Filter
+
+	// KeyColorTransparency makes the pixels matching the extension area key
+	// color fully transparent, for images without alpha. The image is then
+	// returned as an *image.NRGBA.
+	KeyColorTransparency bool
+}
 
 func (d *decoder) decode(r io.Reader) (image.Image, error) {
-	buf, err := io.ReadAll(r)
+	rect, err := d.decodeHeader(r)
 	if err != nil {
 		return nil, err
 	}
 
-	d.rs = bytes.NewReader(buf)
+	return d.decodeImage(rect)
+}
+
+// decodeImage decodes the pixels of rect once the file is loaded.
+func (d *decoder) decodeImage(rect image.Rectangle) (image.Image, error) {
+	pix, stride := make([]byte, rect.Dx()*rect.Dy()*4), rect.Dx()*4
 
-	err = d.read(newSection(footerLen, -footerLen, io.SeekEnd), &d.footer)
+	err := d.decodePixels(pix, stride, rect)
 	if err != nil {
 		return nil, err
 	}
 
-	err = d.read(newSection(headerLen, 0, io.SeekStart), &d.header)
+	gamma := d.gamma()
+	processed := d.cct != nil || gamma != 0
+
+	// color correction and gamma work on non-premultiplied pixels
+	if processed && d.alpha == alphaPremultiplied {
+		unpremultiplyRow(pix)
+	}
+
+	rect = rect.Add(d.origin())
+
+	var img image.Image = &image.NRGBA{Pix: pix, Stride: stride, Rect: rect}
+
+	if d.cct != nil {
+		img = applyColorCorrection(img.(*image.NRGBA), d.cct, d.alpha)
+	}
+
+	if gamma != 0 {
+		applyGamma(img, gamma)
+	}
+
+	if _, ok := img.(*image.NRGBA); ok && d.alpha != alphaStraight && d.key == nil {
+		if processed && d.alpha == alphaPremultiplied {
+			premultiplyRow(pix)
+		}
+
+		img = &image.RGBA{Pix: pix, Stride: stride, Rect: rect}
+	}
+
+	if ratio := d.aspectRatio(); ratio != 0 {
+		img = resampleImage(img, ratio, d.opts.PixelAspect)
+	}
+
+	return img, nil
+}
+
+// gamma returns the exponent that converts the stored pixels to the gamma
+// asked for, or 0 when they are left as they are.
+func (d *decoder) gamma() float64 {
+	if d.opts.Gamma <= 0 || d.file.ExtensionArea == nil || d.file.ExtensionArea.Gamma() <= 0 {
+		return 0
+	}
+
+	if exponent := d.file.ExtensionArea.Gamma() / d.opts.Gamma; exponent != 1 {
+		return exponent
+	}
+
+	return 0
+}
+
+// aspectRatio returns the pixel aspect ratio to correct, or 0 when pixels are
+// left as they are.
+func (d *decoder) aspectRatio() float64 {
+	if d.opts.PixelAspect == 0 || d.file.ExtensionArea == nil {
+		return 0
+	}
+
+	if ratio := d.file.ExtensionArea.AspectRatio(); ratio != 1 {
+		return ratio
+	}
+
+	return 0
+}
+
+// decodeHeader reads everything but the pixels and returns the rectangle
+// that is going to be decoded.
+func (d *decoder) decodeHeader(r io.Reader) (image.Rectangle, error) {
+	if d.ctx == nil {
+		d.ctx = context.Background()
+	}
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		// the file begins where rs is, offsets are moved by as much
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("failed to seek file: %v", err)
+		}
+
+		d.rs = rs
+		if start != 0 {
+			d.rs = &offsetReader{ReadSeeker: rs, start: start}
+		}
+	} else {
+		d.buf.Reset()
+
+		_, err := d.buf.ReadFrom(r)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+
+		d.br.Reset(d.buf.Bytes())
+		d.rs = &d.br
+	}
+
+	if err := d.ctx.Err(); err != nil {
+		return image.Rectangle{}, err
+	}
+
+	// pixels are left in rs, only the rows needed are read later on
+	file, _, err := readMetadata(d.rs)
 	if err != nil {
-		return nil, err
+		return image.Rectangle{}, err
 	}
 
-	d.image = Image{
-		ID:       make([]byte, d.header.IDLength),
-		ColorMap: []byte{},
-		Data:     make([]byte, d.header.ImageBytes()),
+	return d.load(file)
+}
+
+// load prepares decoding the pixels of f and returns the rectangle that is
+// going to be decoded.
+func (d *decoder) load(f File) (image.Rectangle, error) {
+	d.file = f
+
+	d.cct = nil
+	if d.opts.ColorCorrection {
+		d.cct = f.ColorCorrectionTable
 	}
 
-	err = d.read(newSection(int(d.header.IDLength), headerLen, io.SeekStart), d.image.ID)
+	err := checkSupported(f.Header)
 	if err != nil {
-		return nil, err
+		return image.Rectangle{}, err
+	}
+
+	// checked before allocating anything the size of the stretched image
+	if ratio := d.aspectRatio(); ratio > maxAspectRatio || ratio != 0 && ratio < 1.0/maxAspectRatio {
+		return image.Rectangle{}, fmt.Errorf("pixel aspect ratio %d:%d is out of range", f.ExtensionArea.PixelAspectRatio[0], f.ExtensionArea.PixelAspectRatio[1])
+	}
+
+	d.alpha = alphaModeOf(d.file.Header, d.file.ExtensionArea)
+
+	d.key = nil
+	if d.opts.KeyColorTransparency && d.alpha == alphaNone && d.file.ExtensionArea != nil {
+		d.key = keyColorAs(d.file.Header, d.file.ExtensionArea.Key())
+	}
+
+	rect := d.file.Header.Rect()
+	if !d.opts.Region.Empty() {
+		rect = d.opts.Region.Sub(d.origin()).Intersect(rect)
+		if rect.Empty() {
+			return image.Rectangle{}, fmt.Errorf("region %v is outside of the image bounds %v", d.opts.Region, d.file.Header.Rect().Add(d.origin()))
+		}
+	}
+
+	return rect, nil
+}
+
+// origin returns where the top-left pixel of the image is placed.
+func (d *decoder) origin() image.Point {
+	if d.opts.ScreenOrigin {
+		return d.file.Header.Origin()
 	}
 
-	err = d.read(newSection(d.header.ImageBytes(), headerLen+int(d.header.IDLength), io.SeekStart), d.image.Data)
+	return image.Point{}
+}
+
+// checkSupported tells whether the pixels described by h can be decoded.
+func checkSupported(h Header) error {
+	err := h.Validate()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var img image.Image
+	if h.ImageType != UncompressedRGBImage && h.ImageType != RunLengthEncodedRGBImage {
+		return fmt.Errorf("image type '%d' not supported", h.ImageType)
+	}
 
-	switch d.header.ImageType {
-	case UncompressedRGBImage:
-		img = image.NewRGBA(d.header.Rect())
+	switch h.BitsPerPixel {
+	case 15, 16, 24, 32:
 	default:
-		return nil, fmt.Errorf("image type '%d' not supported", d.header.ImageType)
+		return fmt.Errorf("bits per pixel '%d' not supported", h.BitsPerPixel)
 	}
 
-	switch d.header.ImageDescriptor.ImageOrigin() {
-	case BottomLeft:
-		for y := 0; y < img.Bounds().Max.Y; y++ {
-			for x := 0; x < img.Bounds().Max.X; x++ {
-				pixel := d.pixelAt(x, img.Bounds().Max.Y-y-1)
+	return nil
+}
+
+// decodePixels converts the stored pixels covering rect into RGBA, writing
+// them to pix, where rect.Min is at pix[0].
+func (d *decoder) decodePixels(pix []byte, stride int, rect image.Rectangle) error {
+	rowBytes := int(d.file.Header.Width) * d.file.Header.BytesPerPixel()
 
-				img.(*image.RGBA).Set(x, y, color.RGBA{
-					R: pixel[2],
-					G: pixel[1],
-					B: pixel[0],
-					A: 255,
-				})
+	// data holds the stored rows from firstRow on
+	data, firstRow := d.file.Image.Data, 0
+
+	// packed, when set, holds the run-length encoded rows from packedAt on,
+	// which convert expands as it goes, using the scan line table to find
+	// them
+	var (
+		packed   []byte
+		packedAt int64
+		table    ScanLineTable
+	)
+
+	// rle, when set, expands the packets of the stored rows from the first
+	// one on, as convert needs them, without a scan line table
+	var (
+		rle      *rleReader
+		rleMu    sync.Mutex
+		expanded int
+	)
+
+	if len(data) < d.file.Header.ImageBytes() {
+		if d.rs == nil {
+			return fmt.Errorf("image data is missing")
+		}
+
+		// rows are stored contiguously, so only the span of stored rows
+		// covering the region is read
+		firstRow = int(d.file.Header.Height)
+		lastRow := 0
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			row := storedRow(d.file.Header, y)
+			if row < firstRow {
+				firstRow = row
+			}
+			if row+1 > lastRow {
+				lastRow = row + 1
 			}
 		}
-	case TopLeft:
-		for y := 0; y < img.Bounds().Max.Y; y++ {
-			for x := 0; x < img.Bounds().Max.X; x++ {
-				pixel := d.pixelAt(x, y)
 
-				img.(*image.RGBA).Set(x, y, color.RGBA{
-					R: pixel[2],
-					G: pixel[1],
-					B: pixel[0],
-					A: 255,
-				})
+		if isRLE(d.file.Header) {
+			table = d.file.scanLines()
+		}
+
+		switch {
+		case table != nil:
+			d.rows = grow(d.rows, (lastRow-firstRow)*rowBytes)
+			data = d.rows
+
+			packedAt = d.file.size
+			end := int64(0)
+			for _, offset := range table[firstRow:lastRow] {
+				if int64(offset) < packedAt {
+					packedAt = int64(offset)
+				}
+				if int64(offset) > end {
+					end = int64(offset)
+				}
+			}
+
+			end += int64(maxPackedRow(d.file.Header))
+			if end > d.file.size {
+				end = d.file.size
+			}
+
+			d.packed = grow(d.packed, int(end-packedAt))
+			packed = d.packed
+
+			err := read(d.rs, newSection(len(packed), int(packedAt), io.SeekStart), packed)
+			if err != nil {
+				return err
+			}
+		case isRLE(d.file.Header):
+			// without a scan line table, packets are expanded from the
+			// first row on
+			firstRow = 0
+
+			if int64(lastRow*rowBytes) > (d.file.size-d.file.dataOffset())*128 {
+				return fmt.Errorf("run-length encoded data is too short for %d rows", lastRow)
+			}
+
+			d.rows = grow(d.rows, lastRow*rowBytes)
+			data = d.rows
+
+			var err error
+			rle, err = seekRLE(d.rs, d.file.dataOffset(), d.file.Header.BytesPerPixel())
+			if err != nil {
+				return err
+			}
+		default:
+			d.rows = grow(d.rows, (lastRow-firstRow)*rowBytes)
+			data = d.rows
+
+			err := read(d.rs, newSection(len(data), int(d.file.dataOffset())+firstRow*rowBytes, io.SeekStart), data)
+			if err != nil {
+				return err
 			}
 		}
-	case TopRight:
-		for y := 0; y < img.Bounds().Max.Y; y++ {
-			for x := 0; x < img.Bounds().Max.X; x++ {
-				pixel := d.pixelAt(img.Bounds().Max.X-x, y)
+	}
+
+	bytesPerPixel := d.file.Header.BytesPerPixel()
+	maxX := int(d.file.Header.Width) - 1
+
+	origin := d.file.Header.ImageDescriptor.ImageOrigin()
+	rightToLeft := origin == BottomRight || origin == TopRight
+
+	// stored columns covering the region
+	begin, end := rect.Min.X, rect.Max.X
+	if rightToLeft {
+		begin, end = maxX-rect.Max.X+1, maxX-rect.Min.X+1
+	}
+
+	var (
+		mu      sync.Mutex
+		decoded int
+	)
+
+	// expandRows expands the stored rows before n that aren't yet
+	expandRows := func(n int) error {
+		rleMu.Lock()
+		defer rleMu.Unlock()
 
-				img.(*image.RGBA).Set(x, y, color.RGBA{
-					R: pixel[2],
-					G: pixel[1],
-					B: pixel[0],
-					A: 255,
-				})
+		for ; expanded < n; expanded++ {
+			if err := d.ctx.Err(); err != nil {
+				return err
+			}
+
+			err := rle.Read(data[expanded*rowBytes : (expanded+1)*rowBytes])
+			if err != nil {
+				return fmt.Errorf("row %d: %v", expanded, err)
 			}
 		}
-	case BottomRight:
-		for y := 0; y < img.Bounds().Max.Y; y++ {
-			for x := 0; x < img.Bounds().Max.X; x++ {
-				pixel := d.pixelAt(img.Bounds().Max.X-x, img.Bounds().Max.Y-y)
 
-				img.(*image.RGBA).Set(x, y, color.RGBA{
-					R: pixel[2],
-					G: pixel[1],
-					B: pixel[0],
-					A: 255,
-				})
+		return nil
+	}
+
+	convert := func(minY, maxY int) error {
+		for y := minY; y < maxY; y++ {
+			if err := d.ctx.Err(); err != nil {
+				return err
+			}
+
+			row := storedRow(d.file.Header, y)
+
+			src := data[(row-firstRow)*rowBytes:]
+			dst := pix[(y-rect.Min.Y)*stride:]
+
+			if packed != nil {
+				err := expandRow(src[:rowBytes], packed[int64(table[row])-packedAt:], bytesPerPixel)
+				if err != nil {
+					return fmt.Errorf("row %d: %v", row, err)
+				}
+			}
+
+			if rle != nil {
+				err := expandRows(row + 1)
+				if err != nil {
+					return err
+				}
+			}
+
+			swizzleRow(dst[:rect.Dx()*4], src[begin*bytesPerPixel:end*bytesPerPixel], bytesPerPixel, rightToLeft, d.alpha != alphaNone)
+
+			if d.key != nil {
+				clearKeyColor(dst[:rect.Dx()*4], *d.key)
+			}
+
+			if d.opts.Progress != nil {
+				mu.Lock()
+				decoded++
+				d.opts.Progress(decoded, rect.Dy())
+				mu.Unlock()
 			}
 		}
 
+		return nil
 	}
 
-	return img, nil
-}
+	n := d.opts.Concurrency
+	if n > rect.Dy() {
+		n = rect.Dy()
+	}
 
-func (d *decoder) read(config sectionConfig, data any) error {
-	r := bytes.NewBuffer(nil)
+	if n <= 1 {
+		return convert(rect.Min.Y, rect.Max.Y)
+	}
 
-	_, err := d.rs.Seek(config.offset, int(config.whence))
-	if err != nil {
-		return fmt.Errorf("failed to seek file: %v", err)
+	// every goroutine converts its own band of rows
+	var wg sync.WaitGroup
+
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = convert(rect.Min.Y+rect.Dy()*i/n, rect.Min.Y+rect.Dy()*(i+1)/n)
+		}(i)
 	}
 
-	// ensure reader is always in the beginning of the file
-	defer func() {
-		if config.offset != 0 && config.whence != io.SeekStart {
-			d.rs.Seek(0, io.SeekStart)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
 		}
-	}()
+	}
+
+	return nil
+}
+
+// keyColorAs returns the key color as it decodes once stored in the pixel
+// format of h, so that it can be compared with decoded pixels.
+func keyColorAs(h Header, key color.NRGBA) *[4]byte {
+	bytesPerPixel := h.BytesPerPixel()
+
+	stored := make([]byte, bytesPerPixel)
+	unswizzleRow(stored, []byte{key.R, key.G, key.B, 0xff}, bytesPerPixel, false)
+
+	var c [4]byte
+	swizzleRow(c[:], stored, bytesPerPixel, false, false)
+
+	return &c
+}
 
-	_, err = io.CopyN(r, d.rs, config.length)
+// clearKeyColor makes the RGBA pixels matching key fully transparent.
+func clearKeyColor(pix []byte, key [4]byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		if pix[i+0] == key[0] && pix[i+1] == key[1] && pix[i+2] == key[2] {
+			pix[i+0], pix[i+1], pix[i+2], pix[i+3] = 0, 0, 0, 0
+		}
+	}
+}
+
+// offsetReader is a file that begins at start in the underlying reader.
+type offsetReader struct {
+	io.ReadSeeker
+	start int64
+}
+
+func (r *offsetReader) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekStart {
+		offset += r.start
+	}
+
+	n, err := r.ReadSeeker.Seek(offset, whence)
 	if err != nil {
-		return fmt.Errorf("failed to io.CopyN bytes: %v", err)
+		return 0, err
+	}
+
+	if n < r.start {
+		return 0, fmt.Errorf("seek to %d before the beginning of the file", n-r.start)
 	}
 
-	return binary.Read(r, binary.LittleEndian, data)
+	return n - r.start, nil
 }
 
-// TODO: return a color where all colors are already filled
-func (d decoder) pixelAt(x, y int) []byte {
-	if x >= int(d.header.Width) || y >= int(d.header.Height) {
-		return nil
+// grow returns buf resliced to n bytes, only allocating when it is too
+// small.
+func grow(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
 	}
 
-	bytesPerPixel := d.header.BytesPerPixel()
+	return buf[:n]
+}
 
-	x = x * bytesPerPixel
-	y = y * bytesPerPixel
+// applyColorCorrection uses the table as a lookup table for every channel,
+// alpha included unless the image has none.
+func applyColorCorrection(src *image.NRGBA, table *ColorCorrectionTable, alpha alphaMode) *image.NRGBA64 {
+	dst := image.NewNRGBA64(src.Bounds())
 
-	// row * width + column
-	begin := y*int(d.header.Width) + x
+	for i, j := 0, 0; i < len(src.Pix); i, j = i+4, j+8 {
+		r := table[src.Pix[i+0]].R
+		g := table[src.Pix[i+1]].G
+		b := table[src.Pix[i+2]].B
+		a := table[src.Pix[i+3]].A
+		if alpha == alphaNone {
+			// opaque, or cleared as the key color
+			a = uint16(src.Pix[i+3]) * 0x101
+		}
 
-	return d.image.Data[begin : begin+bytesPerPixel]
+		dst.Pix[j+0] = uint8(r >> 8)
+		dst.Pix[j+1] = uint8(r)
+		dst.Pix[j+2] = uint8(g >> 8)
+		dst.Pix[j+3] = uint8(g)
+		dst.Pix[j+4] = uint8(b >> 8)
+		dst.Pix[j+5] = uint8(b)
+		dst.Pix[j+6] = uint8(a >> 8)
+		dst.Pix[j+7] = uint8(a)
+	}
+
+	return dst
+}
+
+// applyGamma raises every color channel, normalized to [0, 1], to exponent.
+// Pixels must not be premultiplied.
+func applyGamma(img image.Image, exponent float64) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		applyGamma8(img.Pix, exponent)
+	case *image.NRGBA64:
+		lut := make([]uint16, 1<<16)
+		for i := range lut {
+			lut[i] = uint16(math.Round(math.Pow(float64(i)/0xffff, exponent) * 0xffff))
+		}
+
+		for i := 0; i < len(img.Pix); i += 8 {
+			for j := i; j < i+6; j += 2 {
+				v := lut[uint16(img.Pix[j])<<8|uint16(img.Pix[j+1])]
+				img.Pix[j+0] = uint8(v >> 8)
+				img.Pix[j+1] = uint8(v)
+			}
+		}
+	}
+}
+
+func applyGamma8(pix []byte, exponent float64) {
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = uint8(math.Round(math.Pow(float64(i)/0xff, exponent) * 0xff))
+	}
+
+	for i := 0; i < len(pix); i += 4 {
+		pix[i+0] = lut[pix[i+0]]
+		pix[i+1] = lut[pix[i+1]]
+		pix[i+2] = lut[pix[i+2]]
+	}
 }
 
 func Decode(r io.Reader) (image.Image, error) {
-	var d decoder
+	return DecodeWithOptions(r, nil)
+}
+
+// DecodeRegion decodes only the pixels inside rect. When r is an
+// io.ReadSeeker, it is read from its beginning and only the rows covering
+// rect are read.
+func DecodeRegion(r io.Reader, rect image.Rectangle) (image.Image, error) {
+	return DecodeWithOptions(r, &DecodeOptions{Region: rect})
+}
+
+func DecodeWithOptions(r io.Reader, opts *DecodeOptions) (image.Image, error) {
+	return DecodeContext(context.Background(), r, opts)
+}
+
+// DecodeContext stops decoding, returning ctx.Err(), as soon as ctx is done.
+// The context is checked between rows.
+func DecodeContext(ctx context.Context, r io.Reader, opts *DecodeOptions) (image.Image, error) {
+	d := decoder{ctx: ctx}
+	if opts != nil {
+		d.opts = *opts
+	}
+
 	return d.decode(r)
 }
+
+// Metadata is everything a file holds besides its pixels.
+type Metadata struct {
+	Header               Header
+	ImageID              []byte
+	Origin               ImageOrigin
+	Version              Version
+	ExtensionArea        *ExtensionArea
+	ColorCorrectionTable *ColorCorrectionTable
+	ScanLineTable        ScanLineTable
+	DeveloperTags        []DeveloperTag
+
+	// Gamma is the gamma the pixels are stored with, or 0 when the file
+	// doesn't tell.
+	Gamma float64
+}
+
+// DecodeWithMetadata decodes the image like Decode, and returns the rest of
+// the file parsed along the way.
+func DecodeWithMetadata(r io.Reader) (image.Image, *Metadata, error) {
+	d := decoder{ctx: context.Background()}
+
+	img, err := d.decode(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// tags whose data is out of the file are returned without it
+	readDeveloperData(d.rs, d.file.size, d.file.DeveloperTags)
+
+	return img, d.metadata(), nil
+}
+
+// metadata returns what was parsed along with the pixels.
+func (d *decoder) metadata() *Metadata {
+	m := Metadata{
+		Header:               d.file.Header,
+		ImageID:              d.file.Image.ID,
+		Origin:               d.file.Header.ImageDescriptor.ImageOrigin(),
+		Version:              d.file.Version(),
+		ExtensionArea:        d.file.ExtensionArea,
+		ColorCorrectionTable: d.file.ColorCorrectionTable,
+		ScanLineTable:        d.file.ScanLineTable,
+		DeveloperTags:        d.file.DeveloperTags,
+	}
+
+	if d.file.ExtensionArea != nil {
+		m.Gamma = d.file.ExtensionArea.Gamma()
+	}
+
+	return &m
+}
+
+// DecodeInto decodes the image into dst, reusing its pixels when they are
+// large enough.
+func DecodeInto(r io.Reader, dst *image.NRGBA) error {
+	return NewDecoder(nil).DecodeInto(r, dst)
+}
+
+// Decoder decodes many images in a row, keeping its buffers between calls.
+// A Decoder must not be used concurrently.
+type Decoder struct {
+	d decoder
+}
+
+func NewDecoder(opts *DecodeOptions) *Decoder {
+	var dec Decoder
+	dec.Reset(opts)
+
+	return &dec
+}
+
+// Reset sets new options, keeping the buffers already allocated.
+func (dec *Decoder) Reset(opts *DecodeOptions) {
+	dec.d.opts = DecodeOptions{}
+	if opts != nil {
+		dec.d.opts = *opts
+	}
+}
+
+func (dec *Decoder) Decode(r io.Reader) (image.Image, error) {
+	return dec.d.decode(r)
+}
+
+// DecodeInto decodes the image into dst, reusing its pixels when they are
+// large enough. Color correction needs 16 bits per channel, so it fails when
+// the ColorCorrection option is set.
+func (dec *Decoder) DecodeInto(r io.Reader, dst *image.NRGBA) error {
+	d := &dec.d
+
+	if d.opts.ColorCorrection {
+		return fmt.Errorf("tga.Decoder.DecodeInto: color correction can't be applied to an *image.NRGBA")
+	}
+
+	rect, err := d.decodeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	dst.Pix = grow(dst.Pix, rect.Dx()*rect.Dy()*4)
+	dst.Stride = rect.Dx() * 4
+	dst.Rect = rect
+
+	err = d.decodePixels(dst.Pix, dst.Stride, rect)
+	if err != nil {
+		return err
+	}
+
+	dst.Rect = rect.Add(d.origin())
+
+	if d.alpha == alphaPremultiplied {
+		unpremultiplyRow(dst.Pix)
+	}
+
+	if gamma := d.gamma(); gamma != 0 {
+		applyGamma(dst, gamma)
+	}
+
+	if ratio := d.aspectRatio(); ratio != 0 {
+		*dst = *resampleImage(dst, ratio, d.opts.PixelAspect).(*image.NRGBA)
+	}
+
+	return nil
+}
@@ -1,7 +1,13 @@
 package tga
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
 	"image"
+	"image/color"
+	"io"
 	"os"
 	"reflect"
 	"testing"
@@ -24,27 +30,59 @@ func decodeTGA(filename string) (image.Image, error) {
 	return Decode(f)
 }
 
+// pixOf returns the pixels of the 8-bit images Decode returns.
+func pixOf(img image.Image) ([]byte, int) {
+	switch img := img.(type) {
+	case *image.RGBA:
+		return img.Pix, img.Stride
+	case *image.NRGBA:
+		return img.Pix, img.Stride
+	}
+
+	return nil, 0
+}
+
 func TestDecode(t *testing.T) {
 	testCases := map[string]struct {
 		filename string
-		want     image.Image
+		bounds   image.Rectangle
+		pixels   map[image.Point]color.Color
 	}{
 		"DecodeTGA32BottomLeft": {
 			filename: "test.tga",
-			want:     nil,
-		},
-		// "DecodeTGA24BottomLeft": {
-		//	filename: "test2.tga",
-		//	want:     nil,
-		// },
-		// "DecodeTGA16TopLeft": {
-		//	filename: "flag_t16.tga",
-		//	want:     nil,
-		// },
-		// "DecodeTGA24TopLeft": {
-		//	filename: "xing_t24.tga",
-		//	want:     nil,
-		// },
+			bounds:   image.Rect(0, 0, 256, 256),
+			pixels: map[image.Point]color.Color{
+				{0, 0}:     color.NRGBA{R: 0, G: 0, B: 0, A: 0},
+				{128, 128}: color.NRGBA{R: 0x28, G: 0x2e, B: 0x41, A: 255},
+			},
+		},
+		"DecodeTGA24BottomLeft": {
+			filename: "test2.tga",
+			bounds:   image.Rect(0, 0, 1280, 853),
+			pixels: map[image.Point]color.Color{
+				{0, 0}:      color.RGBA{R: 178, G: 132, B: 145, A: 255},
+				{640, 400}:  color.RGBA{R: 226, G: 185, B: 183, A: 255},
+				{1279, 852}: color.RGBA{R: 104, G: 108, B: 13, A: 255},
+			},
+		},
+		"DecodeTGA16TopLeft": {
+			filename: "flag_t16.tga",
+			bounds:   image.Rect(0, 0, 124, 124),
+			pixels: map[image.Point]color.Color{
+				{0, 0}:     color.RGBA{R: 255, G: 0, B: 255, A: 255},
+				{60, 60}:   color.RGBA{R: 255, G: 0, B: 0, A: 255},
+				{123, 123}: color.RGBA{R: 255, G: 0, B: 0, A: 255},
+			},
+		},
+		"DecodeTGA24TopLeft": {
+			filename: "xing_t24.tga",
+			bounds:   image.Rect(0, 0, 240, 164),
+			pixels: map[image.Point]color.Color{
+				{0, 0}:     color.RGBA{R: 41, G: 100, B: 63, A: 255},
+				{120, 80}:  color.RGBA{R: 255, G: 51, B: 107, A: 255},
+				{239, 163}: color.RGBA{R: 34, G: 52, B: 49, A: 255},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -53,8 +91,1104 @@ func TestDecode(t *testing.T) {
 			t.Fatalf("%s: unexpected error: %v", name, err)
 		}
 
-		if !reflect.DeepEqual(tc.want, got) {
-			t.Errorf("%s: different image from what is expected", name)
+		if got.Bounds() != tc.bounds {
+			t.Errorf("%s: expected bounds %v, but got %v", name, tc.bounds, got.Bounds())
+		}
+
+		for p, want := range tc.pixels {
+			if c := got.At(p.X, p.Y); !reflect.DeepEqual(want, c) {
+				t.Errorf("%s: pixel %v: expected %v, but got %v", name, p, want, c)
+			}
+		}
+	}
+}
+
+func TestDecodeWithOptions(t *testing.T) {
+	var table ColorCorrectionTable
+	for i := range table {
+		table[i] = ColorCorrection{A: 0xffff, R: uint16(i) << 8, G: 0x1234, B: 0xffff - uint16(i)}
+	}
+
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	testCases := []struct {
+		opts     *DecodeOptions
+		expected color.Color
+	}{
+		{
+			opts:     nil,
+			expected: color.RGBA{R: 3, G: 2, B: 1, A: 255},
+		},
+		{
+			opts:     &DecodeOptions{ColorCorrection: false},
+			expected: color.RGBA{R: 3, G: 2, B: 1, A: 255},
+		},
+		{
+			opts:     &DecodeOptions{ColorCorrection: true},
+			expected: color.NRGBA64{R: 0x0300, G: 0x1234, B: 0xfffe, A: 0xffff},
+		},
+	}
+
+	for i, tc := range testCases {
+		input := bytes.NewReader(newTestFile(t, header, []byte{1, 2, 3}, &ExtensionArea{}, &table, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if c := got.At(0, 0); !reflect.DeepEqual(tc.expected, c) {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.expected, c)
 		}
 	}
 }
+
+func TestDecodeGamma(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	testCases := []struct {
+		gamma    [2]uint16
+		opts     *DecodeOptions
+		expected color.Color
+	}{
+		{
+			gamma:    [2]uint16{22, 10},
+			opts:     nil,
+			expected: color.RGBA{R: 192, G: 128, B: 64, A: 255},
+		},
+		{
+			gamma:    [2]uint16{22, 10},
+			opts:     &DecodeOptions{Gamma: 1},
+			expected: color.RGBA{R: 137, G: 56, B: 12, A: 255},
+		},
+		{
+			gamma:    [2]uint16{22, 10},
+			opts:     &DecodeOptions{Gamma: 2.2},
+			expected: color.RGBA{R: 192, G: 128, B: 64, A: 255},
+		},
+		{
+			gamma:    [2]uint16{1, 1},
+			opts:     &DecodeOptions{Gamma: 2.2},
+			expected: color.RGBA{R: 224, G: 186, B: 136, A: 255},
+		},
+		{
+			gamma:    [2]uint16{0, 0},
+			opts:     &DecodeOptions{Gamma: 2.2},
+			expected: color.RGBA{R: 192, G: 128, B: 64, A: 255},
+		},
+	}
+
+	for i, tc := range testCases {
+		ext := &ExtensionArea{GammaValue: tc.gamma}
+		input := bytes.NewReader(newTestFile(t, header, []byte{64, 128, 192}, ext, nil, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if c := got.At(0, 0); !reflect.DeepEqual(tc.expected, c) {
+			t.Errorf("test %d: expected %v, but got %v", i+1, tc.expected, c)
+		}
+	}
+}
+
+func TestDecodeRegion(t *testing.T) {
+	testCases := []struct {
+		origin ImageDescriptor
+		rect   image.Rectangle
+	}{
+		{origin: 0, rect: image.Rect(1, 1, 3, 2)},
+		{origin: 16, rect: image.Rect(0, 0, 2, 2)},
+		{origin: 32, rect: image.Rect(2, 0, 4, 3)},
+		{origin: 48, rect: image.Rect(1, 2, 2, 3)},
+		{origin: 0, rect: image.Rect(-5, -5, 2, 1)},
+	}
+
+	for i, tc := range testCases {
+		header := Header{ImageType: UncompressedRGBImage, Width: 4, Height: 3, BitsPerPixel: 24, ImageDescriptor: tc.origin}
+
+		data := make([]byte, header.ImageBytes())
+		for j := range data {
+			data[j] = byte(j)
+		}
+
+		file := newTestFile(t, header, data, nil, nil, false)
+
+		full, err := Decode(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		got, err := DecodeRegion(bytes.NewReader(file), tc.rect)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		expected := full.(interface {
+			SubImage(image.Rectangle) image.Image
+		}).SubImage(tc.rect)
+
+		if got.Bounds() != expected.Bounds() {
+			t.Fatalf("test %d: expected bounds %v, but got %v", i+1, expected.Bounds(), got.Bounds())
+		}
+
+		for y := expected.Bounds().Min.Y; y < expected.Bounds().Max.Y; y++ {
+			for x := expected.Bounds().Min.X; x < expected.Bounds().Max.X; x++ {
+				if !reflect.DeepEqual(expected.At(x, y), got.At(x, y)) {
+					t.Errorf("test %d: pixel (%d, %d): expected %v, but got %v", i+1, x, y, expected.At(x, y), got.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeRegionOutOfBounds(t *testing.T) {
+	f, err := os.Open("./testdata/test.tga")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	defer f.Close()
+
+	_, err = DecodeRegion(f, image.Rect(300, 300, 400, 400))
+	if err == nil {
+		t.Errorf("expected an error for a region outside of the image")
+	}
+}
+
+func TestDecodeAtOffset(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 4))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i * 7)
+		if i%4 == 3 {
+			src.Pix[i] = 255
+		}
+	}
+
+	inputs := map[string][]byte{"run-length encoded": writeRLE(t, src, BottomLeft, true)}
+
+	for _, filename := range testFiles {
+		data, err := os.ReadFile("./testdata/" + filename)
+		if err != nil {
+			t.Fatalf("%s: failed to open test file: %v", filename, err)
+		}
+
+		inputs[filename] = data
+	}
+
+	for name, data := range inputs {
+		expected, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		// the file follows a prefix the reader is already past
+		r := bytes.NewReader(append([]byte("prefix"), data...))
+		if _, err := r.Seek(6, io.SeekStart); err != nil {
+			t.Fatalf("%s: failed to seek: %v", name, err)
+		}
+
+		got, err := Decode(r)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("%s: expected the same image as without the prefix", name)
+		}
+	}
+}
+
+// writeRLE writes img, which must be opaque, as a run-length encoded Targa 24
+// image with the given origin. Every row is packed on its own, runs of equal
+// pixels as run packets and the others as raw packets.
+func writeRLE(t *testing.T, img *image.NRGBA, origin ImageOrigin, scanLines bool) []byte {
+	t.Helper()
+
+	descriptor := [...]ImageDescriptor{BottomLeft: 0, BottomRight: 16, TopLeft: 32, TopRight: 48}[origin]
+	header := Header{ImageType: RunLengthEncodedRGBImage, Width: uint16(img.Rect.Dx()), Height: uint16(img.Rect.Dy()), BitsPerPixel: 24, ImageDescriptor: descriptor}
+
+	var packets []byte
+
+	offsets := make(ScanLineTable, header.Height)
+	for y := range offsets {
+		offsets[y] = uint32(headerLen + len(packets))
+
+		row := y
+		if origin == BottomLeft || origin == BottomRight {
+			row = img.Rect.Dy() - 1 - y
+		}
+
+		pix := append([]byte(nil), img.Pix[row*img.Stride:(row+1)*img.Stride]...)
+		if origin == BottomRight || origin == TopRight {
+			for l, r := 0, len(pix)-4; l < r; l, r = l+4, r-4 {
+				for k := 0; k < 4; k++ {
+					pix[l+k], pix[r+k] = pix[r+k], pix[l+k]
+				}
+			}
+		}
+
+		for x := 0; x < len(pix); {
+			n := 1
+			for x+n*4 < len(pix) && n < 128 && bytes.Equal(pix[x:x+3], pix[x+n*4:x+n*4+3]) {
+				n++
+			}
+
+			if n > 1 {
+				packets = append(packets, 0x80|byte(n-1), pix[x+2], pix[x+1], pix[x])
+			} else {
+				packets = append(packets, 0x00, pix[x+2], pix[x+1], pix[x])
+			}
+
+			x += n * 4
+		}
+	}
+
+	var buf bytes.Buffer
+
+	write := func(data any) {
+		if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	write(header)
+	write(packets)
+
+	footer := Footer{Point: '.'}
+	copy(footer.Signature[:], "TRUEVISION-XFILE")
+
+	if scanLines {
+		ext := ExtensionArea{ExtensionSize: extensionAreaLen, ScanLineOffset: uint32(buf.Len())}
+		write(offsets)
+
+		footer.ExtensionAreaOffset = uint32(buf.Len())
+		write(ext)
+	}
+
+	write(footer)
+
+	return buf.Bytes()
+}
+
+func TestDecodeRunLengthEncoded(t *testing.T) {
+	// pairs of equal pixels, so rows hold both run and raw packets
+	src := image.NewNRGBA(image.Rect(0, 0, 5, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 5; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x / 2 * 40), G: uint8(y * 30), B: uint8(x % 3), A: 255})
+		}
+	}
+
+	testCases := []struct {
+		origin    ImageOrigin
+		scanLines bool
+		opts      DecodeOptions
+	}{
+		{origin: TopLeft},
+		{origin: BottomLeft},
+		{origin: TopRight, scanLines: true},
+		{origin: BottomLeft, scanLines: true},
+		{origin: BottomLeft, opts: DecodeOptions{Concurrency: 3}},
+		{origin: BottomLeft, scanLines: true, opts: DecodeOptions{Concurrency: 3}},
+		{origin: TopLeft, opts: DecodeOptions{Region: image.Rect(1, 2, 4, 4)}},
+		{origin: BottomRight, scanLines: true, opts: DecodeOptions{Region: image.Rect(1, 2, 4, 4), Concurrency: 2}},
+	}
+
+	for i, tc := range testCases {
+		data := writeRLE(t, src, tc.origin, tc.scanLines)
+
+		img, err := DecodeWithOptions(bytes.NewReader(data), &tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		bounds := src.Bounds()
+		if !tc.opts.Region.Empty() {
+			bounds = tc.opts.Region
+		}
+
+		if img.Bounds() != bounds {
+			t.Fatalf("test %d: expected bounds %v, but got %v", i+1, bounds, img.Bounds())
+		}
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				expected := color.RGBAModel.Convert(src.At(x, y))
+				if got := img.At(x, y); got != expected {
+					t.Errorf("test %d: pixel (%d, %d): expected %v, but got %v", i+1, x, y, expected, got)
+				}
+			}
+		}
+
+		file, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		// stored rows, bottom-up and right-to-left depending on the origin
+		var stored []byte
+		for y := 0; y < src.Rect.Dy(); y++ {
+			row := y
+			if tc.origin == BottomLeft || tc.origin == BottomRight {
+				row = src.Rect.Dy() - 1 - y
+			}
+
+			for x := 0; x < src.Rect.Dx(); x++ {
+				column := x
+				if tc.origin == BottomRight || tc.origin == TopRight {
+					column = src.Rect.Dx() - 1 - x
+				}
+
+				c := src.NRGBAAt(column, row)
+				stored = append(stored, c.B, c.G, c.R)
+			}
+		}
+
+		if !bytes.Equal(stored, file.Image.Data) {
+			t.Errorf("test %d: expected Image.Data `%v`, but got `%v`", i+1, stored, file.Image.Data)
+		}
+
+		expected, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		opened, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("test %d: failed to Open file: %v", i+1, err)
+		}
+
+		if opened.Image.Data != nil {
+			t.Errorf("test %d: expected Open not to expand the image data", i+1)
+		}
+
+		if got := opened.PixelAt(3, 4); !bytes.Equal(file.PixelAt(3, 4), got) {
+			t.Errorf("test %d: PixelAt(3, 4): expected `%v`, but got `%v`", i+1, file.PixelAt(3, 4), got)
+		}
+
+		if got, err := opened.Decode(); err != nil || !reflect.DeepEqual(expected, got) {
+			t.Errorf("test %d: expected File.Decode to match Decode, got error %v", i+1, err)
+		}
+
+		rr, err := NewRowReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		pix, stride := expected.(*image.RGBA).Pix, expected.(*image.RGBA).Stride
+		row := make([]byte, stride)
+
+		for y := 0; y < src.Rect.Dy(); y++ {
+			err := rr.ReadRow(row)
+			if err != nil {
+				t.Fatalf("test %d: row %d: unexpected error: %v", i+1, y, err)
+			}
+
+			if !bytes.Equal(pix[y*stride:(y+1)*stride], row) {
+				t.Errorf("test %d: row %d differs from Decode", i+1, y)
+			}
+		}
+	}
+}
+
+func TestDecodeRunLengthEncodedPackets(t *testing.T) {
+	header := Header{ImageType: RunLengthEncodedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32}
+
+	testCases := []struct {
+		packets  []byte
+		expected []byte
+	}{
+		// a run crossing rows
+		{packets: []byte{0x82, 3, 2, 1, 0x00, 6, 5, 4}, expected: []byte{1, 2, 3, 255, 1, 2, 3, 255, 1, 2, 3, 255, 4, 5, 6, 255}},
+		// a raw packet crossing rows
+		{packets: []byte{0x02, 3, 2, 1, 6, 5, 4, 9, 8, 7, 0x80, 1, 1, 1}, expected: []byte{1, 2, 3, 255, 4, 5, 6, 255, 7, 8, 9, 255, 1, 1, 1, 255}},
+		// data ending early
+		{packets: []byte{0x00, 3, 2, 1, 0x00, 6, 5, 4, 0x00, 9}},
+	}
+
+	for i, tc := range testCases {
+		// without the footer, so that nothing follows the packets
+		file := newTestFile(t, header, tc.packets, nil, nil, false)
+
+		img, err := Decode(bytes.NewReader(file[:len(file)-footerLen]))
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("test %d: expected an error for truncated packets", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if got := img.(*image.RGBA).Pix; !bytes.Equal(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+func BenchmarkDecode(b *testing.B) {
+	origins := []ImageDescriptor{0, 16, 32, 48}
+
+	for _, bitsPerPixel := range []byte{16, 24, 32} {
+		for _, origin := range origins {
+			header := Header{
+				ImageType:       UncompressedRGBImage,
+				Width:           1024,
+				Height:          1024,
+				BitsPerPixel:    bitsPerPixel,
+				ImageDescriptor: origin,
+			}
+
+			file := newBenchmarkFile(header)
+
+			b.Run(fmt.Sprintf("Targa%d/%s", bitsPerPixel, origin.ImageOrigin()), func(b *testing.B) {
+				b.SetBytes(int64(header.ImageBytes()))
+
+				for i := 0; i < b.N; i++ {
+					_, err := Decode(bytes.NewReader(file))
+					if err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func newBenchmarkFile(header Header) []byte {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.LittleEndian, header)
+
+	data := make([]byte, header.ImageBytes())
+	for i := range data {
+		data[i] = byte(i)
+	}
+	buf.Write(data)
+
+	binary.Write(&buf, binary.LittleEndian, Footer{})
+
+	return buf.Bytes()
+}
+
+func TestDecodeInto(t *testing.T) {
+	dec := NewDecoder(nil)
+	dst := &image.NRGBA{}
+
+	for _, filename := range testFiles {
+		expected, err := decodeTGA(filename)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", filename, err)
+		}
+
+		f, err := os.Open("./testdata/" + filename)
+		if err != nil {
+			t.Fatalf("%s: failed to open test file: %v", filename, err)
+		}
+
+		err = dec.DecodeInto(f, dst)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", filename, err)
+		}
+
+		if dst.Bounds() != expected.Bounds() {
+			t.Fatalf("%s: expected bounds %v, but got %v", filename, expected.Bounds(), dst.Bounds())
+		}
+
+		if pix, _ := pixOf(expected); !bytes.Equal(pix, dst.Pix) {
+			t.Errorf("%s: pixels differ from Decode", filename)
+		}
+	}
+}
+
+func TestDecodeIntoReusesPixels(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24}
+	file := newTestFile(t, header, make([]byte, header.ImageBytes()), nil, nil, false)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	pix := &dst.Pix[0]
+
+	err := DecodeInto(bytes.NewReader(file), dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dst.Bounds() != header.Rect() {
+		t.Errorf("expected bounds %v, but got %v", header.Rect(), dst.Bounds())
+	}
+
+	if &dst.Pix[0] != pix {
+		t.Errorf("expected dst.Pix to be reused")
+	}
+}
+
+func TestDecodeColorCorrectionAlpha(t *testing.T) {
+	var table ColorCorrectionTable
+	for i := range table {
+		v := uint16(i) * 0x101
+		table[i] = ColorCorrection{A: 0xffff - v, R: v, G: v, B: v}
+	}
+
+	testCases := []struct {
+		header   Header
+		ext      ExtensionArea
+		data     []byte
+		opts     DecodeOptions
+		expected []color.Color
+	}{
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 32, ImageDescriptor: 8},
+			ext:      ExtensionArea{AttributesType: UsefulAlpha},
+			data:     []byte{1, 2, 3, 128},
+			opts:     DecodeOptions{ColorCorrection: true},
+			expected: []color.Color{color.NRGBA64{R: 0x0303, G: 0x0202, B: 0x0101, A: 0x7f7f}},
+		},
+		{
+			// images without alpha stay opaque
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24},
+			data:     []byte{1, 2, 3},
+			opts:     DecodeOptions{ColorCorrection: true},
+			expected: []color.Color{color.NRGBA64{R: 0x0303, G: 0x0202, B: 0x0101, A: 0xffff}},
+		},
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 2, Height: 1, BitsPerPixel: 24},
+			ext:      ExtensionArea{KeyColor: 0xff030201},
+			data:     []byte{1, 2, 3, 4, 5, 6},
+			opts:     DecodeOptions{ColorCorrection: true, KeyColorTransparency: true},
+			expected: []color.Color{color.NRGBA64{}, color.NRGBA64{R: 0x0606, G: 0x0505, B: 0x0404, A: 0xffff}},
+		},
+	}
+
+	for i, tc := range testCases {
+		data := newTestFile(t, tc.header, tc.data, &tc.ext, &table, false)
+
+		got, err := DecodeWithOptions(bytes.NewReader(data), &tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		for x, expected := range tc.expected {
+			if c := got.At(x, 0); c != expected {
+				t.Errorf("test %d: expected %v at (%d, 0), but got %v", i+1, expected, x, c)
+			}
+		}
+
+		err = NewDecoder(&tc.opts).DecodeInto(bytes.NewReader(data), &image.NRGBA{})
+		if err == nil {
+			t.Errorf("test %d: expected DecodeInto to fail with color correction", i+1)
+		}
+	}
+}
+
+func BenchmarkDecoderDecodeInto(b *testing.B) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1024, Height: 1024, BitsPerPixel: 32}
+	file := newBenchmarkFile(header)
+
+	dec := NewDecoder(nil)
+	dst := &image.NRGBA{}
+
+	b.SetBytes(int64(header.ImageBytes()))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		err := dec.DecodeInto(bytes.NewReader(file), dst)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestDecodeConcurrency(t *testing.T) {
+	testCases := []struct {
+		filename string
+		opts     DecodeOptions
+	}{
+		{filename: "test.tga", opts: DecodeOptions{Concurrency: 4}},
+		{filename: "test2.tga", opts: DecodeOptions{Concurrency: 7}},
+		{filename: "flag_t16.tga", opts: DecodeOptions{Concurrency: 3, Region: image.Rect(10, 20, 50, 23)}},
+		{filename: "xing_t24.tga", opts: DecodeOptions{Concurrency: 1000}},
+	}
+
+	for i, tc := range testCases {
+		data, err := os.ReadFile("./testdata/" + tc.filename)
+		if err != nil {
+			t.Fatalf("test %d: failed to open test file: %v", i+1, err)
+		}
+
+		serial := tc.opts
+		serial.Concurrency = 0
+
+		expected, err := DecodeWithOptions(bytes.NewReader(data), &serial)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		got, err := DecodeWithOptions(bytes.NewReader(data), &tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("test %d: concurrent decoding of `%s` differs from serial decoding", i+1, tc.filename)
+		}
+	}
+}
+
+func BenchmarkDecodeConcurrency(b *testing.B) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 4096, Height: 4096, BitsPerPixel: 32}
+	file := newBenchmarkFile(header)
+
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Concurrency%d", concurrency), func(b *testing.B) {
+			b.SetBytes(int64(header.ImageBytes()))
+
+			for i := 0; i < b.N; i++ {
+				_, err := DecodeWithOptions(bytes.NewReader(file), &DecodeOptions{Concurrency: concurrency})
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	data, err := os.ReadFile("./testdata/xing_t24.tga")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+
+	testCases := []struct {
+		cancelAt    int // row after which the context is canceled, -1 cancels before decoding
+		concurrency int
+		expected    error
+	}{
+		{cancelAt: 0, expected: nil},
+		{cancelAt: 0, concurrency: 4, expected: nil},
+		{cancelAt: -1, expected: context.Canceled},
+		{cancelAt: 10, expected: context.Canceled},
+		{cancelAt: 10, concurrency: 4, expected: context.Canceled},
+	}
+
+	for i, tc := range testCases {
+		ctx, cancel := context.WithCancel(context.Background())
+		if tc.cancelAt < 0 {
+			cancel()
+		}
+
+		var last, calls int
+
+		opts := &DecodeOptions{
+			Concurrency: tc.concurrency,
+			Progress: func(rows, total int) {
+				calls++
+				last = rows
+
+				if total != 164 {
+					t.Errorf("test %d: expected a total of 164 rows, but got %d", i+1, total)
+				}
+
+				if rows == tc.cancelAt {
+					cancel()
+				}
+			},
+		}
+
+		_, err := DecodeContext(ctx, bytes.NewReader(data), opts)
+		cancel()
+
+		if err != tc.expected {
+			t.Errorf("test %d: expected error `%v`, but got `%v`", i+1, tc.expected, err)
+		}
+
+		if err == nil && (last != 164 || calls != 164) {
+			t.Errorf("test %d: expected 164 rows to be reported, but got %d in %d calls", i+1, last, calls)
+		}
+
+		if err != nil && calls >= 164 {
+			t.Errorf("test %d: expected decoding to stop early, but %d rows were reported", i+1, calls)
+		}
+	}
+}
+
+// cancelingReader cancels once more than after bytes were read.
+type cancelingReader struct {
+	*bytes.Reader
+	read, after int
+	cancel      func()
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+
+	r.read += n
+	if r.read > r.after {
+		r.cancel()
+	}
+
+	return n, err
+}
+
+func TestDecodeContextRunLengthEncoded(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i*31 + i/251)
+		if i%4 == 3 {
+			src.Pix[i] = 255
+		}
+	}
+
+	for _, origin := range []ImageOrigin{TopLeft, BottomLeft} {
+		data := writeRLE(t, src, origin, false)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		r := &cancelingReader{Reader: bytes.NewReader(data), after: len(data) / 4, cancel: cancel}
+
+		_, err := DecodeContext(ctx, r, nil)
+		cancel()
+
+		if err != context.Canceled {
+			t.Errorf("%v: expected error `%v`, but got `%v`", origin, context.Canceled, err)
+		}
+
+		if r.read > len(data)/2 {
+			t.Errorf("%v: expected packets to stop being read early, but %d of %d bytes were read", origin, r.read, len(data))
+		}
+	}
+}
+
+func TestDecodeAlpha(t *testing.T) {
+	testCases := []struct {
+		ext             *ExtensionArea
+		imageDescriptor ImageDescriptor
+		opts            *DecodeOptions
+		expected        color.Color
+	}{
+		{ext: nil, imageDescriptor: 0, expected: color.RGBA{R: 30, G: 20, B: 10, A: 255}},
+		{ext: nil, imageDescriptor: 8, expected: color.NRGBA{R: 30, G: 20, B: 10, A: 128}},
+		{ext: &ExtensionArea{AttributesType: NoAlpha}, imageDescriptor: 8, expected: color.RGBA{R: 30, G: 20, B: 10, A: 255}},
+		{ext: &ExtensionArea{AttributesType: UndefinedAlphaIgnore}, imageDescriptor: 8, expected: color.RGBA{R: 30, G: 20, B: 10, A: 255}},
+		{ext: &ExtensionArea{AttributesType: UndefinedAlphaRetain}, imageDescriptor: 8, expected: color.NRGBA{R: 30, G: 20, B: 10, A: 128}},
+		{ext: &ExtensionArea{AttributesType: UsefulAlpha}, imageDescriptor: 8, expected: color.NRGBA{R: 30, G: 20, B: 10, A: 128}},
+		{ext: &ExtensionArea{AttributesType: PremultipliedAlpha}, imageDescriptor: 8, expected: color.RGBA{R: 30, G: 20, B: 10, A: 128}},
+		{
+			ext:             &ExtensionArea{AttributesType: PremultipliedAlpha, GammaValue: [2]uint16{1, 1}},
+			imageDescriptor: 8,
+			opts:            &DecodeOptions{Gamma: 2},
+			expected:        color.RGBA{R: 62, G: 50, B: 35, A: 128},
+		},
+	}
+
+	for i, tc := range testCases {
+		header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 32, ImageDescriptor: tc.imageDescriptor}
+		input := bytes.NewReader(newTestFile(t, header, []byte{10, 20, 30, 128}, tc.ext, nil, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if c := got.At(0, 0); !reflect.DeepEqual(tc.expected, c) {
+			t.Errorf("test %d: expected %#v, but got %#v", i+1, tc.expected, c)
+		}
+	}
+}
+
+func TestDecodeKeyColor(t *testing.T) {
+	magenta := color.NRGBA{R: 255, B: 255, A: 255}
+	clear := color.NRGBA{}
+
+	testCases := []struct {
+		bitsPerPixel    byte
+		imageDescriptor ImageDescriptor
+		attributesType  byte
+		keyColor        uint32
+		data            []byte
+		opts            *DecodeOptions
+		expected        []color.Color
+	}{
+		{
+			bitsPerPixel: 24,
+			keyColor:     0xffff00ff,
+			data:         []byte{255, 0, 255, 10, 20, 30},
+			opts:         &DecodeOptions{KeyColorTransparency: true},
+			expected:     []color.Color{clear, color.NRGBA{R: 30, G: 20, B: 10, A: 255}},
+		},
+		{
+			bitsPerPixel: 24,
+			keyColor:     0xffff00ff,
+			data:         []byte{255, 0, 255, 10, 20, 30},
+			opts:         nil,
+			expected:     []color.Color{color.RGBA(magenta), color.RGBA{R: 30, G: 20, B: 10, A: 255}},
+		},
+		{
+			// the key is compared at the 5-bit precision of the pixels
+			bitsPerPixel: 16,
+			keyColor:     0x00fa03fc,
+			data:         []byte{0x1f, 0x7c, 0x00, 0x7c},
+			opts:         &DecodeOptions{KeyColorTransparency: true},
+			expected:     []color.Color{clear, color.NRGBA{R: 255, A: 255}},
+		},
+		{
+			// images with alpha keep it
+			bitsPerPixel:    32,
+			imageDescriptor: 8,
+			attributesType:  UsefulAlpha,
+			keyColor:        0xffff00ff,
+			data:            []byte{255, 0, 255, 255, 10, 20, 30, 128},
+			opts:            &DecodeOptions{KeyColorTransparency: true},
+			expected:        []color.Color{magenta, color.NRGBA{R: 30, G: 20, B: 10, A: 128}},
+		},
+	}
+
+	for i, tc := range testCases {
+		header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 1, BitsPerPixel: tc.bitsPerPixel, ImageDescriptor: tc.imageDescriptor}
+		ext := ExtensionArea{KeyColor: tc.keyColor, AttributesType: tc.attributesType}
+		input := bytes.NewReader(newTestFile(t, header, tc.data, &ext, nil, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		for x, expected := range tc.expected {
+			if c := got.At(x, 0); !reflect.DeepEqual(expected, c) {
+				t.Errorf("test %d: expected %#v at (%d, 0), but got %#v", i+1, expected, x, c)
+			}
+		}
+	}
+}
+
+func TestDecodeScreenOrigin(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, XOrigin: 100, YOrigin: 50, Width: 2, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32}
+	data := []byte{1, 1, 1, 2, 2, 2, 3, 3, 3, 4, 4, 4}
+
+	testCases := []struct {
+		opts   *DecodeOptions
+		bounds image.Rectangle
+	}{
+		{opts: nil, bounds: image.Rect(0, 0, 2, 2)},
+		{opts: &DecodeOptions{ScreenOrigin: true}, bounds: image.Rect(100, 50, 102, 52)},
+		{opts: &DecodeOptions{ScreenOrigin: true, Region: image.Rect(101, 51, 200, 200)}, bounds: image.Rect(101, 51, 102, 52)},
+	}
+
+	for i, tc := range testCases {
+		input := bytes.NewReader(newTestFile(t, header, data, nil, nil, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if got.Bounds() != tc.bounds {
+			t.Errorf("test %d: expected bounds %v, but got %v", i+1, tc.bounds, got.Bounds())
+		}
+
+		// the last pixel stays the same wherever the image is placed
+		expected := color.RGBA{R: 4, G: 4, B: 4, A: 255}
+		if c := got.At(tc.bounds.Max.X-1, tc.bounds.Max.Y-1); c != expected {
+			t.Errorf("test %d: expected %v, but got %v", i+1, expected, c)
+		}
+	}
+
+	var dst image.NRGBA
+
+	err := NewDecoder(&DecodeOptions{ScreenOrigin: true}).DecodeInto(bytes.NewReader(newTestFile(t, header, data, nil, nil, false)), &dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := image.Rect(100, 50, 102, 52); dst.Rect != expected {
+		t.Errorf("expected bounds %v, but got %v", expected, dst.Rect)
+	}
+}
+
+func TestDecodePixelAspect(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32}
+	data := []byte{1, 1, 1, 2, 2, 2, 3, 3, 3, 4, 4, 4}
+
+	testCases := []struct {
+		pixelAspectRatio [2]uint16
+		opts             *DecodeOptions
+		bounds           image.Rectangle
+	}{
+		{pixelAspectRatio: [2]uint16{2, 1}, opts: nil, bounds: image.Rect(0, 0, 2, 2)},
+		{pixelAspectRatio: [2]uint16{2, 1}, opts: &DecodeOptions{PixelAspect: NearestNeighbor}, bounds: image.Rect(0, 0, 4, 2)},
+		{pixelAspectRatio: [2]uint16{1, 2}, opts: &DecodeOptions{PixelAspect: Bilinear}, bounds: image.Rect(0, 0, 2, 4)},
+		{pixelAspectRatio: [2]uint16{1, 1}, opts: &DecodeOptions{PixelAspect: Bilinear}, bounds: image.Rect(0, 0, 2, 2)},
+		{pixelAspectRatio: [2]uint16{0, 0}, opts: &DecodeOptions{PixelAspect: Bilinear}, bounds: image.Rect(0, 0, 2, 2)},
+	}
+
+	for i, tc := range testCases {
+		ext := ExtensionArea{PixelAspectRatio: tc.pixelAspectRatio}
+		input := bytes.NewReader(newTestFile(t, header, data, &ext, nil, false))
+
+		got, err := DecodeWithOptions(input, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if got.Bounds() != tc.bounds {
+			t.Fatalf("test %d: expected bounds %v, but got %v", i+1, tc.bounds, got.Bounds())
+		}
+
+		// corners keep their colors
+		expected := color.RGBA{R: 4, G: 4, B: 4, A: 255}
+		if c := got.At(tc.bounds.Max.X-1, tc.bounds.Max.Y-1); c != expected {
+			t.Errorf("test %d: expected %v, but got %v", i+1, expected, c)
+		}
+	}
+}
+
+func TestDecodePixelAspectOutOfRange(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24}
+
+	testCases := []struct {
+		pixelAspectRatio [2]uint16
+		valid            bool
+	}{
+		{pixelAspectRatio: [2]uint16{16, 1}, valid: true},
+		{pixelAspectRatio: [2]uint16{1, 16}, valid: true},
+		{pixelAspectRatio: [2]uint16{17, 1}, valid: false},
+		{pixelAspectRatio: [2]uint16{65535, 1}, valid: false},
+		{pixelAspectRatio: [2]uint16{1, 65535}, valid: false},
+	}
+
+	for i, tc := range testCases {
+		ext := ExtensionArea{PixelAspectRatio: tc.pixelAspectRatio}
+		data := newTestFile(t, header, []byte{1, 2, 3}, &ext, nil, false)
+
+		_, err := DecodeWithOptions(bytes.NewReader(data), &DecodeOptions{PixelAspect: Bilinear})
+		if tc.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i+1, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("test %d: expected an error for a %d:%d pixel aspect ratio", i+1, tc.pixelAspectRatio[0], tc.pixelAspectRatio[1])
+		}
+
+		// the ratio only matters when correcting it
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			t.Errorf("test %d: unexpected error without PixelAspect: %v", i+1, err)
+		}
+	}
+}
+
+func TestDecodeInterleaved(t *testing.T) {
+	testCases := []struct {
+		imageDescriptor ImageDescriptor
+		data            []byte
+	}{
+		{imageDescriptor: 32, data: []byte{0, 0, 0, 1, 1, 1, 2, 2, 2, 3, 3, 3, 4, 4, 4}},
+		{imageDescriptor: 64 | 32, data: []byte{0, 0, 0, 2, 2, 2, 4, 4, 4, 1, 1, 1, 3, 3, 3}},
+		{imageDescriptor: 128 | 32, data: []byte{0, 0, 0, 4, 4, 4, 1, 1, 1, 2, 2, 2, 3, 3, 3}},
+		{imageDescriptor: 64, data: []byte{4, 4, 4, 2, 2, 2, 0, 0, 0, 3, 3, 3, 1, 1, 1}},
+	}
+
+	for i, tc := range testCases {
+		header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 5, BitsPerPixel: 24, ImageDescriptor: tc.imageDescriptor}
+		data := newTestFile(t, header, tc.data, nil, nil, false)
+
+		got, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		region, err := DecodeRegion(bytes.NewReader(data), image.Rect(0, 1, 1, 3))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		file, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		for y := 0; y < 5; y++ {
+			expected := color.RGBA{R: uint8(y), G: uint8(y), B: uint8(y), A: 255}
+
+			if c := got.At(0, y); c != expected {
+				t.Errorf("test %d: expected %v at row %d, but got %v", i+1, expected, y, c)
+			}
+
+			if c := file.At(0, y); c != expected {
+				t.Errorf("test %d: expected %v at row %d of File, but got %v", i+1, expected, y, c)
+			}
+
+			if y >= 1 && y < 3 {
+				if c := region.At(0, y); c != expected {
+					t.Errorf("test %d: expected %v at row %d of the region, but got %v", i+1, expected, y, c)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeWithMetadata(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32}
+
+	var table ColorCorrectionTable
+	for i := range table {
+		table[i] = ColorCorrection{A: 0xffff, R: uint16(i) * 0x101, G: uint16(i) * 0x101, B: uint16(i) * 0x101}
+	}
+
+	ext := ExtensionArea{GammaValue: [2]uint16{22, 10}}
+	withExt := newTestFile(t, header, []byte{1, 2, 3, 4, 5, 6}, &ext, &table, true)
+
+	tags := []DeveloperTag{{Tag: 3, Data: []byte("tag")}}
+	withTags := newDeveloperTestFile(t, header, []byte{1, 2, 3, 4, 5, 6}, tags)
+
+	testCases := []struct {
+		data     []byte
+		expected Metadata
+	}{
+		{
+			data: withExt,
+			expected: Metadata{
+				Header:               header,
+				ImageID:              []byte{},
+				Origin:               TopLeft,
+				Version:              NewTGA,
+				ExtensionArea:        &ext,
+				ColorCorrectionTable: &table,
+				ScanLineTable:        ScanLineTable{18, 21},
+				Gamma:                2.2,
+			},
+		},
+		{
+			data: withTags,
+			expected: Metadata{
+				Header:        header,
+				ImageID:       []byte{},
+				Origin:        TopLeft,
+				Version:       NewTGA,
+				DeveloperTags: tags,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		img, m, err := DecodeWithMetadata(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if img.Bounds() != header.Rect() {
+			t.Errorf("test %d: expected bounds %v, but got %v", i+1, header.Rect(), img.Bounds())
+		}
+
+		if !reflect.DeepEqual(&tc.expected, m) {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, &tc.expected, m)
+		}
+	}
+}
+
+func TestDecodeInvalidHeader(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24, ImageDescriptor: 8}
+	input := bytes.NewReader(newTestFile(t, header, []byte{1, 2, 3}, nil, nil, false))
+
+	if _, err := Decode(input); err == nil {
+		t.Errorf("expected an error for 8 alpha bits in a 24-bit image")
+	}
+}
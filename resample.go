@@ -0,0 +1,109 @@
+package tga
+
+import (
+	"image"
+	"math"
+)
+
+// Filter is the interpolation used to resample images.
+type Filter int
+
+const (
+	NearestNeighbor Filter = iota + 1
+	Bilinear
+)
+
+// maxAspectRatio bounds how much an image can be stretched, as the ratio
+// comes straight from the file.
+const maxAspectRatio = 16
+
+// squareSize returns the size w×h pixels of the given aspect ratio take once
+// they are square. Images are only ever stretched.
+func squareSize(w, h int, ratio float64) (int, int) {
+	if ratio > 1 {
+		return int(math.Round(float64(w) * ratio)), h
+	}
+
+	return w, int(math.Round(float64(h) / ratio))
+}
+
+// resampleImage stretches img, decoded with pixels of the given aspect
+// ratio, so that its pixels become square. Bounds keep their Min.
+func resampleImage(img image.Image, ratio float64, filter Filter) image.Image {
+	r := img.Bounds()
+	w, h := squareSize(r.Dx(), r.Dy(), ratio)
+	rect := image.Rectangle{Min: r.Min, Max: r.Min.Add(image.Pt(w, h))}
+
+	// decoded images have no padding between rows
+	switch img := img.(type) {
+	case *image.RGBA:
+		return &image.RGBA{Pix: resample(img.Pix, r.Dx(), r.Dy(), w, h, 1, filter), Stride: 4 * w, Rect: rect}
+	case *image.NRGBA:
+		return &image.NRGBA{Pix: resample(img.Pix, r.Dx(), r.Dy(), w, h, 1, filter), Stride: 4 * w, Rect: rect}
+	case *image.NRGBA64:
+		return &image.NRGBA64{Pix: resample(img.Pix, r.Dx(), r.Dy(), w, h, 2, filter), Stride: 8 * w, Rect: rect}
+	}
+
+	return img
+}
+
+// resample scales pix, holding w×h pixels of 4 big-endian channels of
+// channelLen bytes each, to nw×nh pixels.
+func resample(pix []byte, w, h, nw, nh, channelLen int, filter Filter) []byte {
+	pixelLen := 4 * channelLen
+	dst := make([]byte, nw*nh*pixelLen)
+
+	get := func(x, y, c int) float64 {
+		i := (y*w+x)*pixelLen + c*channelLen
+		if channelLen == 2 {
+			return float64(uint16(pix[i])<<8 | uint16(pix[i+1]))
+		}
+
+		return float64(pix[i])
+	}
+
+	set := func(i int, v float64) {
+		if channelLen == 2 {
+			u := uint16(math.Round(v))
+			dst[i], dst[i+1] = uint8(u>>8), uint8(u)
+			return
+		}
+
+		dst[i] = uint8(math.Round(v))
+	}
+
+	// source coordinate of the center of a destination pixel
+	source := func(x, n, nn int) float64 {
+		v := (float64(x)+0.5)*float64(n)/float64(nn) - 0.5
+		return math.Max(0, math.Min(v, float64(n-1)))
+	}
+
+	for y, i := 0, 0; y < nh; y++ {
+		for x := 0; x < nw; x, i = x+1, i+pixelLen {
+			if filter != Bilinear {
+				j := (y*h/nh*w + x*w/nw) * pixelLen
+				copy(dst[i:i+pixelLen], pix[j:j+pixelLen])
+				continue
+			}
+
+			fx, fy := source(x, w, nw), source(y, h, nh)
+			x0, y0 := int(fx), int(fy)
+			x1, y1 := x0, y0
+			if x1 < w-1 {
+				x1++
+			}
+			if y1 < h-1 {
+				y1++
+			}
+			tx, ty := fx-float64(x0), fy-float64(y0)
+
+			for c := 0; c < 4; c++ {
+				top := get(x0, y0, c)*(1-tx) + get(x1, y0, c)*tx
+				bottom := get(x0, y1, c)*(1-tx) + get(x1, y1, c)*tx
+				set(i+c*channelLen, top*(1-ty)+bottom*ty)
+			}
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,63 @@
+package tga
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSquareSize(t *testing.T) {
+	testCases := []struct {
+		w, h   int
+		ratio  float64
+		nw, nh int
+	}{
+		{w: 10, h: 10, ratio: 1, nw: 10, nh: 10},
+		{w: 10, h: 10, ratio: 2, nw: 20, nh: 10},
+		{w: 10, h: 10, ratio: 0.5, nw: 10, nh: 20},
+		{w: 720, h: 486, ratio: 10.0 / 11, nw: 720, nh: 535},
+	}
+
+	for i, tc := range testCases {
+		nw, nh := squareSize(tc.w, tc.h, tc.ratio)
+		if nw != tc.nw || nh != tc.nh {
+			t.Errorf("test %d: expected %dx%d, but got %dx%d", i+1, tc.nw, tc.nh, nw, nh)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	// 2x1 pixels, black and white
+	pix := []byte{0, 0, 0, 255, 255, 255, 255, 255}
+
+	testCases := []struct {
+		filter   Filter
+		expected []byte
+	}{
+		{
+			filter:   NearestNeighbor,
+			expected: []byte{0, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255, 255, 255, 255, 255, 255},
+		},
+		{
+			filter:   Bilinear,
+			expected: []byte{0, 0, 0, 255, 64, 64, 64, 255, 191, 191, 191, 255, 255, 255, 255, 255},
+		},
+	}
+
+	for i, tc := range testCases {
+		got := resample(pix, 2, 1, 4, 1, 1, tc.filter)
+		if !bytes.Equal(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestResample16(t *testing.T) {
+	pix := []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	got := resample(pix, 1, 2, 1, 3, 2, Bilinear)
+
+	expected := []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff, 0x80, 0x00, 0x80, 0x00, 0x80, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if !bytes.Equal(expected, got) {
+		t.Errorf("expected `%v`, but got `%v`", expected, got)
+	}
+}
@@ -0,0 +1,198 @@
+package tga
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// byteReader is what packets are expanded from.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// rleReader expands run-length encoded packets into stored pixels. Packets
+// may cross rows, what is left of one is kept for the next call to Read.
+type rleReader struct {
+	r             byteReader
+	bytesPerPixel int
+
+	rleState
+}
+
+// rleState is where the reader is: the offset in the file of the next byte
+// of packets, and what is left of the current packet.
+type rleState struct {
+	offset int64
+	left   int     // pixels left in the current packet
+	raw    bool    // whether the current packet is a raw one
+	pixel  [4]byte // repeated by the current run packet
+}
+
+func newRLEReader(r byteReader, bytesPerPixel int) *rleReader {
+	return &rleReader{r: r, bytesPerPixel: bytesPerPixel}
+}
+
+// Read fills dst, which holds whole pixels, with expanded pixels.
+func (rr *rleReader) Read(dst []byte) error {
+	bpp := rr.bytesPerPixel
+
+	for i := 0; i < len(dst); {
+		if rr.left == 0 {
+			b, err := rr.r.ReadByte()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+
+			rr.offset++
+
+			rr.left = int(b&0x7f) + 1
+			rr.raw = b&0x80 == 0
+
+			if !rr.raw {
+				_, err = io.ReadFull(rr.r, rr.pixel[:bpp])
+				if err != nil {
+					return unexpectedEOF(err)
+				}
+
+				rr.offset += int64(bpp)
+			}
+		}
+
+		n := rr.left
+		if room := (len(dst) - i) / bpp; n > room {
+			n = room
+		}
+
+		if rr.raw {
+			_, err := io.ReadFull(rr.r, dst[i:i+n*bpp])
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+
+			rr.offset += int64(n * bpp)
+		} else {
+			for j := 0; j < n; j++ {
+				copy(dst[i+j*bpp:], rr.pixel[:bpp])
+			}
+		}
+
+		i += n * bpp
+		rr.left -= n
+	}
+
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("run-length encoded data: %v", err)
+}
+
+// isRLE tells whether the pixels described by h are stored as run-length
+// encoded packets.
+func isRLE(h Header) bool {
+	switch h.ImageType {
+	case RunLengthEncodedColorMappedImage, RunLengthEncodedRGBImage, RunLengthEncodedGrayscaleImage:
+		return true
+	}
+
+	return false
+}
+
+// readRLE expands the packets found at offset in rs until dst is full.
+func readRLE(rs io.ReadSeeker, offset int64, dst []byte, bytesPerPixel int) error {
+	rr, err := seekRLE(rs, offset, bytesPerPixel)
+	if err != nil {
+		return err
+	}
+
+	return rr.Read(dst)
+}
+
+// seekRLE returns a reader of the packets found at offset in rs.
+func seekRLE(rs io.ReadSeeker, offset int64, bytesPerPixel int) (*rleReader, error) {
+	return resumeRLE(rs, rleState{offset: offset}, bytesPerPixel)
+}
+
+// resumeRLE returns a reader of the packets of rs picking up where another
+// one was when it had the given state.
+func resumeRLE(rs io.ReadSeeker, state rleState, bytesPerPixel int) (*rleReader, error) {
+	_, err := rs.Seek(state.offset, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek file: %v", err)
+	}
+
+	rr := newRLEReader(bufio.NewReader(rs), bytesPerPixel)
+	rr.rleState = state
+
+	return rr, nil
+}
+
+// expansion keeps the pixels of a lazily opened run-length encoded file,
+// expanded at most once.
+type expansion struct {
+	once sync.Once
+	data []byte
+}
+
+// expandImage expands all the pixel data of f, read from rs.
+func (f File) expandImage(rs io.ReadSeeker) ([]byte, error) {
+	err := f.checkPacked()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, f.Header.ImageBytes())
+
+	err = readRLE(rs, f.dataOffset(), data, f.Header.BytesPerPixel())
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// checkPacked tells files too short for their run-length encoded image,
+// before it is allocated: a packet holds at most 128 pixels.
+func (f File) checkPacked() error {
+	if int64(f.Header.ImageBytes()) > (f.size-f.dataOffset())*128 {
+		return fmt.Errorf("run-length encoded data is too short for %dx%d pixels", f.Header.Width, f.Header.Height)
+	}
+
+	return nil
+}
+
+// expandRow expands the stored row whose packets begin at packed[0].
+func expandRow(dst, packed []byte, bytesPerPixel int) error {
+	return newRLEReader(bytes.NewReader(packed), bytesPerPixel).Read(dst)
+}
+
+// scanLines returns the scan line table when rows can be found with it: it
+// has an offset for every row, all of them within the file and after the
+// color map.
+func (f File) scanLines() ScanLineTable {
+	if len(f.ScanLineTable) != int(f.Header.Height) || f.size == 0 {
+		return nil
+	}
+
+	for _, offset := range f.ScanLineTable {
+		if int64(offset) < f.dataOffset() || int64(offset) >= f.size {
+			return nil
+		}
+	}
+
+	return f.ScanLineTable
+}
+
+// maxPackedRow is the most bytes a row of width pixels can take once run
+// length encoded: a raw packet per pixel.
+func maxPackedRow(h Header) int {
+	return int(h.Width) * (h.BytesPerPixel() + 1)
+}
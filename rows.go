@@ -0,0 +1,187 @@
+package tga
+
+import (
+	"fmt"
+	"io"
+)
+
+// rowBufferLen bounds the stored rows a RowReader holds at once.
+const rowBufferLen = 1 << 20
+
+// RowReader reads an image one row at a time, top-down whatever the origin,
+// without loading the whole image. Rows stored bottom-up are read in blocks
+// from the end, so memory is bounded by rowBufferLen and a single row.
+type RowReader struct {
+	File File // every section but Image.Data
+
+	rs       io.ReadSeeker
+	y        int
+	rowBytes int
+	buf      []byte
+	first    int // first stored row in buf
+	rows     int // number of stored rows in buf
+
+	rle  *rleReader // expands run-length encoded rows from next on
+	next int
+
+	// index holds where the run-length encoded rows expanded so far begin,
+	// for going back to them without a scan line table
+	index []rleState
+}
+
+func NewRowReader(rs io.ReadSeeker) (*RowReader, error) {
+	file, _, err := readMetadata(rs)
+	if err != nil {
+		return nil, fmt.Errorf("tga.NewRowReader: %v", err)
+	}
+
+	err = checkSupported(file.Header)
+	if err != nil {
+		return nil, fmt.Errorf("tga.NewRowReader: %v", err)
+	}
+
+	rowBytes := int(file.Header.Width) * file.Header.BytesPerPixel()
+
+	n := 1
+	if rowBytes > 0 && rowBufferLen/rowBytes > n {
+		n = rowBufferLen / rowBytes
+	}
+
+	if n > int(file.Header.Height) {
+		n = int(file.Header.Height)
+	}
+
+	return &RowReader{
+		File:     file,
+		rs:       rs,
+		rowBytes: rowBytes,
+		buf:      make([]byte, n*rowBytes),
+	}, nil
+}
+
+// ReadRow converts the next row into non-premultiplied RGBA pixels in dst,
+// which must hold Width*4 bytes. Alpha is full unless the file has useful
+// alpha, the same way Decode tells. It returns io.EOF once every row was
+// read.
+func (rr *RowReader) ReadRow(dst []byte) error {
+	h := rr.File.Header
+
+	if rr.y >= int(h.Height) {
+		return io.EOF
+	}
+
+	if len(dst) < int(h.Width)*4 {
+		return fmt.Errorf("tga.RowReader: row needs %d bytes, got %d", int(h.Width)*4, len(dst))
+	}
+
+	origin := h.ImageDescriptor.ImageOrigin()
+
+	row := storedRow(h, rr.y)
+
+	if rr.rowBytes > 0 && (row < rr.first || row >= rr.first+rr.rows) {
+		err := rr.fill(row, origin == BottomLeft || origin == BottomRight)
+		if err != nil {
+			return fmt.Errorf("tga.RowReader: failed to read row %d: %v", rr.y, err)
+		}
+	}
+
+	alpha := alphaModeOf(h, rr.File.ExtensionArea)
+
+	src := rr.buf[(row-rr.first)*rr.rowBytes:][:rr.rowBytes]
+	swizzleRow(dst[:int(h.Width)*4], src, h.BytesPerPixel(), origin == BottomRight || origin == TopRight, alpha != alphaNone)
+
+	if alpha == alphaPremultiplied {
+		unpremultiplyRow(dst[:int(h.Width)*4])
+	}
+
+	rr.y++
+
+	return nil
+}
+
+// fill loads a block of stored rows holding row, which is the last row of
+// the block when reading bottom-up and the first one otherwise.
+func (rr *RowReader) fill(row int, bottomUp bool) error {
+	n := len(rr.buf) / rr.rowBytes
+
+	first := row
+	if bottomUp {
+		first = row - n + 1
+		if first < 0 {
+			first = 0
+		}
+
+		n = row - first + 1
+	} else if first+n > int(rr.File.Header.Height) {
+		n = int(rr.File.Header.Height) - first
+	}
+
+	var err error
+	if isRLE(rr.File.Header) {
+		err = rr.expand(first, n)
+	} else {
+		offset := int(rr.File.dataOffset()) + first*rr.rowBytes
+		err = read(rr.rs, newSection(n*rr.rowBytes, offset, io.SeekStart), rr.buf[:n*rr.rowBytes])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rr.first, rr.rows = first, n
+
+	return nil
+}
+
+// expand loads n run-length encoded stored rows, from first on, into buf.
+func (rr *RowReader) expand(first, n int) error {
+	dst := rr.buf[:n*rr.rowBytes]
+	bytesPerPixel := rr.File.Header.BytesPerPixel()
+
+	if table := rr.File.scanLines(); table != nil {
+		return readRLE(rr.rs, int64(table[first]), dst, bytesPerPixel)
+	}
+
+	// without a scan line table, packets are expanded from the first row
+	// on, going back to rows expanded before through the index
+	if rr.rle == nil || first < rr.next {
+		row, state := 0, rleState{offset: rr.File.dataOffset()}
+		if len(rr.index) > 0 {
+			row = first
+			if row >= len(rr.index) {
+				row = len(rr.index) - 1
+			}
+
+			state = rr.index[row]
+		}
+
+		var err error
+		rr.rle, err = resumeRLE(rr.rs, state, bytesPerPixel)
+		if err != nil {
+			return err
+		}
+
+		rr.next = row
+	}
+
+	for rr.next < first+n {
+		if rr.next == len(rr.index) {
+			rr.index = append(rr.index, rr.rle.rleState)
+		}
+
+		row := rr.buf[:rr.rowBytes]
+		if rr.next >= first {
+			row = dst[(rr.next-first)*rr.rowBytes:][:rr.rowBytes]
+		}
+
+		err := rr.rle.Read(row)
+		if err != nil {
+			rr.rle = nil
+			return err
+		}
+
+		rr.next++
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package tga
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRowReader(t *testing.T) {
+	for _, filename := range testFiles {
+		expected, err := decodeTGA(filename)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", filename, err)
+		}
+
+		f, err := os.Open("./testdata/" + filename)
+		if err != nil {
+			t.Fatalf("%s: failed to open test file: %v", filename, err)
+		}
+		defer f.Close()
+
+		rr, err := NewRowReader(f)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", filename, err)
+		}
+
+		pix, stride := pixOf(expected)
+		row := make([]byte, stride)
+
+		for y := 0; ; y++ {
+			err := rr.ReadRow(row)
+			if err == io.EOF {
+				if y != expected.Bounds().Dy() {
+					t.Errorf("%s: expected %d rows, but got %d", filename, expected.Bounds().Dy(), y)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: row %d: unexpected error: %v", filename, y, err)
+			}
+
+			if !bytes.Equal(pix[y*stride:(y+1)*stride], row) {
+				t.Errorf("%s: row %d differs from Decode", filename, y)
+			}
+		}
+	}
+}
+
+func TestRowReaderShortRow(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24}
+	file := newTestFile(t, header, make([]byte, header.ImageBytes()), nil, nil, false)
+
+	rr, err := NewRowReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rr.ReadRow(make([]byte, 7))
+	if err == nil {
+		t.Errorf("expected an error for a row buffer that is too small")
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	*bytes.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+
+	return n, err
+}
+
+func TestRowReaderRunLengthEncodedBottomUp(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i*31 + i/251)
+		if i%4 == 3 {
+			src.Pix[i] = 255
+		}
+	}
+
+	header := Header{ImageType: RunLengthEncodedRGBImage, Width: 3, Height: 4, BitsPerPixel: 24}
+
+	inputs := map[string][]byte{
+		"rows": writeRLE(t, src, BottomLeft, false),
+		// a run, a raw packet and a run, all crossing rows
+		"packets": newTestFile(t, header, []byte{
+			0x84, 1, 1, 1,
+			0x03, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 5, 5,
+			0x82, 6, 6, 6,
+		}, nil, nil, false),
+	}
+
+	for name, data := range inputs {
+		expected, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		r := &countingReader{Reader: bytes.NewReader(data)}
+
+		rr, err := NewRowReader(r)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		// a row at a time, so every row is found going backwards
+		rr.buf = rr.buf[:rr.rowBytes]
+
+		pix, stride := pixOf(expected)
+		row := make([]byte, stride)
+
+		for y := 0; y < expected.Bounds().Dy(); y++ {
+			err := rr.ReadRow(row)
+			if err != nil {
+				t.Fatalf("%s: row %d: unexpected error: %v", name, y, err)
+			}
+
+			if !bytes.Equal(pix[y*stride:(y+1)*stride], row) {
+				t.Errorf("%s: row %d differs from Decode", name, y)
+			}
+		}
+
+		// the packets once, then a buffered read for every row gone back to
+		if limit := 2*len(data) + expected.Bounds().Dy()*4096; r.read > limit {
+			t.Errorf("%s: expected at most %d bytes to be read, but got %d", name, limit, r.read)
+		}
+	}
+}
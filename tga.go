@@ -3,12 +3,15 @@
 package tga
 
 import (
-	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"io"
+	"math"
+	"strings"
 )
 
 type ImageType byte
@@ -32,13 +35,25 @@ const (
 )
 
 type File struct {
-	Header Header
-	Image  Image
-	Footer Footer
+	Header               Header
+	Image                Image
+	ExtensionArea        *ExtensionArea
+	ColorCorrectionTable *ColorCorrectionTable
+	ScanLineTable        ScanLineTable
+	DeveloperTags        []DeveloperTag
+	Footer               Footer
+
+	// src, when set by Open, is where pixels are read from on demand
+	src io.ReaderAt
+	rle *expansion // run-length encoded pixels of src, once expanded
+
+	size      int64   // of the whole file
+	stampSize [2]byte // width and height of the postage stamp, if any
 }
 
 func (f File) Pixels() [][]byte {
 	pixels := make([][]byte, 0)
+	data := f.imageData()
 
 	for i := 0; i < f.Header.ImageBytes(); i += f.Header.BytesPerPixel() {
 		end := i + f.Header.BytesPerPixel()
@@ -47,7 +62,7 @@ func (f File) Pixels() [][]byte {
 			end = f.Header.ImageBytes()
 		}
 
-		pixels = append(pixels, f.Image.Data[i:end])
+		pixels = append(pixels, data[i:end])
 	}
 
 	return pixels
@@ -66,27 +81,334 @@ func (f File) PixelAt(x, y int) []byte {
 	// row * width + column
 	begin := y*int(f.Header.Width) + x
 
-	return f.Image.Data[begin : begin+bytesPerPixel]
+	if f.Image.Data == nil && f.src != nil && f.rle == nil {
+		pixel := make([]byte, bytesPerPixel)
+
+		_, err := f.src.ReadAt(pixel, f.dataOffset()+int64(begin))
+		if err != nil {
+			return nil
+		}
+
+		return pixel
+	}
+
+	data := f.imageData()
+	if data == nil {
+		return nil
+	}
+
+	return data[begin : begin+bytesPerPixel]
+}
+
+// ColorAt returns the pixel at (x, y), like PixelAt, as one of the typed
+// colors of the package: BGR888, BGRA8888, ARGB1555, GrayAlpha or
+// color.Gray. The attribute bit of Targa 16 is only used as alpha when
+// ImageDescriptor reserves an alpha bit.
+func (f File) ColorAt(x, y int) color.Color {
+	pixel := f.PixelAt(x, y)
+	if pixel == nil {
+		return nil
+	}
+
+	gray := f.Header.ImageType == UncompressedGrayscaleImage || f.Header.ImageType == RunLengthEncodedGrayscaleImage
+
+	switch {
+	case gray && len(pixel) == 1:
+		return color.Gray{Y: pixel[0]}
+	case gray && len(pixel) == 2:
+		return GrayAlpha{Y: pixel[0], A: pixel[1]}
+	case len(pixel) == 2:
+		c := ARGB1555(pixel[0]) | ARGB1555(pixel[1])<<8
+		if f.Header.ImageDescriptor.AlphaBits() == 0 {
+			c |= 0x8000
+		}
+
+		return c
+	case len(pixel) == 3:
+		return BGR888{B: pixel[0], G: pixel[1], R: pixel[2]}
+	case len(pixel) == 4:
+		return BGRA8888{B: pixel[0], G: pixel[1], R: pixel[2], A: pixel[3]}
+	}
+
+	return nil
+}
+
+// At returns the pixel at (x, y) in display coordinates, honoring the image
+// origin, with the same color type Decode would give it.
+func (f File) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(f.Bounds())) {
+		return color.RGBA{}
+	}
+
+	pixel := f.PixelAt(f.storedPoint(x, y))
+	if pixel == nil {
+		return color.RGBA{}
+	}
+
+	alpha := alphaModeOf(f.Header, f.ExtensionArea)
+
+	var c [4]byte
+	swizzleRow(c[:], pixel, len(pixel), false, alpha != alphaNone)
+
+	if alpha == alphaStraight {
+		return color.NRGBA{R: c[0], G: c[1], B: c[2], A: c[3]}
+	}
+
+	return color.RGBA{R: c[0], G: c[1], B: c[2], A: c[3]}
+}
+
+// storedPoint maps display coordinates to the coordinates PixelAt takes.
+func (f File) storedPoint(x, y int) (int, int) {
+	origin := f.Header.ImageDescriptor.ImageOrigin()
+
+	if origin == BottomRight || origin == TopRight {
+		x = int(f.Header.Width) - 1 - x
+	}
+
+	return x, storedRow(f.Header, y)
+}
+
+// storedRow returns the index, in storage order, of the row displayed at y,
+// honoring the image origin and interleaving.
+func storedRow(h Header, y int) int {
+	height := int(h.Height)
+
+	// lines are counted from the origin
+	if origin := h.ImageDescriptor.ImageOrigin(); origin == BottomLeft || origin == BottomRight {
+		y = height - 1 - y
+	}
+
+	// every n-th line is stored in a pass, the first pass starting with
+	// line 0, the next one with line 1 and so on
+	n := h.ImageDescriptor.Interleave().ways()
+
+	row := y / n
+	for pass := 0; pass < y%n; pass++ {
+		row += (height - pass + n - 1) / n
+	}
+
+	return row
+}
+
+// Each calls fn for every pixel, in display order whatever the image origin,
+// with its typed color as ColorAt returns it. Iteration stops when fn
+// returns false.
+func (f File) Each(fn func(x, y int, c color.Color) bool) {
+	it := f.Iter()
+
+	for it.Next() {
+		if !fn(it.Pixel()) {
+			return
+		}
+	}
+}
+
+// Iter returns a cursor over the pixels of f in display order, starting
+// before the top-left pixel.
+func (f File) Iter() *PixelIterator {
+	// lazily opened files are read once rather than pixel by pixel
+	f.Image.Data = f.imageData()
+
+	return &PixelIterator{f: f, x: -1}
+}
+
+// PixelIterator walks the pixels of a File row by row, top-down, in display
+// coordinates.
+type PixelIterator struct {
+	f    File
+	x, y int
+}
+
+// Next moves to the next pixel and tells whether there was one.
+func (it *PixelIterator) Next() bool {
+	if it.y >= int(it.f.Header.Height) || it.f.Header.Width == 0 {
+		return false
+	}
+
+	it.x++
+	if it.x == int(it.f.Header.Width) {
+		it.x = 0
+		it.y++
+	}
+
+	return it.y < int(it.f.Header.Height)
+}
+
+// Pixel returns the coordinates and typed color of the current pixel.
+func (it *PixelIterator) Pixel() (x, y int, c color.Color) {
+	return it.x, it.y, it.f.ColorAt(it.f.storedPoint(it.x, it.y))
+}
+
+func (f File) Bounds() image.Rectangle {
+	return f.Header.Rect()
 }
 
-// RGBA only supports Targa 24 and Targa 32 for now
+func (f File) ColorModel() color.Model {
+	if alphaModeOf(f.Header, f.ExtensionArea) == alphaStraight {
+		return color.NRGBAModel
+	}
+
+	return color.RGBAModel
+}
+
+// dataOffset is where the image data begins in the file, after the image ID
+// and the color map.
+func (f File) dataOffset() int64 {
+	return int64(headerSection.length) + int64(f.Header.IDLength) + int64(f.Header.colorMapBytes())
+}
+
+// imageData returns Image.Data, reading all of it from the source when the
+// file was opened lazily.
+func (f File) imageData() []byte {
+	if f.Image.Data != nil || f.src == nil {
+		return f.Image.Data
+	}
+
+	if f.rle != nil {
+		f.rle.once.Do(func() {
+			f.rle.data, _ = f.expandImage(io.NewSectionReader(f.src, 0, f.size))
+		})
+
+		return f.rle.data
+	}
+
+	data := make([]byte, f.Header.ImageBytes())
+
+	_, err := f.src.ReadAt(data, f.dataOffset())
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// Decode converts the pixels of f into an image exactly like Decode does
+// for the file f was read from. Files from Open have their pixels read from
+// the source.
+func (f File) Decode() (image.Image, error) {
+	d := decoder{ctx: context.Background()}
+	if f.src != nil && f.Image.Data == nil {
+		d.rs = io.NewSectionReader(f.src, 0, f.dataOffset()+int64(f.Header.ImageBytes()))
+		if f.rle != nil {
+			d.rs = io.NewSectionReader(f.src, 0, f.size)
+		}
+	}
+
+	rect, err := d.load(f)
+	if err != nil {
+		return nil, fmt.Errorf("tga.File.Decode: %v", err)
+	}
+
+	img, err := d.decodeImage(rect)
+	if err != nil {
+		return nil, fmt.Errorf("tga.File.Decode: %v", err)
+	}
+
+	return img, nil
+}
+
+// RGBA returns the pixels of f in display order, premultiplied by alpha
+// when the file has any. The image is left blank when f can't be decoded,
+// Decode tells why.
 func (f File) RGBA() *image.RGBA {
-	img := image.NewRGBA(image.Rect(0, 0, int(f.Header.Width), int(f.Header.Height)))
-
-	for y := 0; y < img.Bounds().Max.Y; y++ {
-		for x := 0; x < img.Bounds().Max.X; x++ {
-			pixel := f.PixelAt(x, y)
-
-			img.Set(x, y, color.RGBA{
-				R: pixel[2],
-				G: pixel[1],
-				B: pixel[0],
-				A: 255,
-			})
+	img, err := f.Decode()
+	if err != nil {
+		return image.NewRGBA(f.Bounds())
+	}
+
+	if img, ok := img.(*image.RGBA); ok {
+		return img
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return rgba
+}
+
+// swizzleRow converts a row of stored pixels (BGR, BGRA or ARGB1555) into
+// RGBA. Alpha is only copied when alpha is set and is full otherwise. src
+// runs right-to-left when reverse is set.
+func swizzleRow(dst, src []byte, bytesPerPixel int, reverse, alpha bool) {
+	j, step := 0, 4
+	if reverse {
+		j, step = len(dst)-4, -4
+	}
+
+	switch bytesPerPixel {
+	case 2:
+		for i := 0; i+1 < len(src); i, j = i+2, j+step {
+			v := uint16(src[i]) | uint16(src[i+1])<<8
+			r, g, b := byte(v>>10)&0x1f, byte(v>>5)&0x1f, byte(v)&0x1f
+
+			dst[j+0] = r<<3 | r>>2
+			dst[j+1] = g<<3 | g>>2
+			dst[j+2] = b<<3 | b>>2
+			dst[j+3] = 255
+
+			if alpha && v&0x8000 == 0 {
+				dst[j+3] = 0
+			}
+		}
+	case 3:
+		for i := 0; i+2 < len(src); i, j = i+3, j+step {
+			dst[j+0] = src[i+2]
+			dst[j+1] = src[i+1]
+			dst[j+2] = src[i+0]
+			dst[j+3] = 255
+		}
+	case 4:
+		for i := 0; i+3 < len(src); i, j = i+4, j+step {
+			dst[j+0] = src[i+2]
+			dst[j+1] = src[i+1]
+			dst[j+2] = src[i+0]
+			dst[j+3] = 255
+
+			if alpha {
+				dst[j+3] = src[i+3]
+			}
 		}
 	}
+}
+
+// unpremultiplyRow turns premultiplied RGBA pixels into non-premultiplied
+// ones, in place.
+func unpremultiplyRow(pix []byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		if a == 0xff || a == 0 {
+			continue
+		}
 
-	return img
+		pix[i+0] = uint8(min255(uint32(pix[i+0]) * 0xff / a))
+		pix[i+1] = uint8(min255(uint32(pix[i+1]) * 0xff / a))
+		pix[i+2] = uint8(min255(uint32(pix[i+2]) * 0xff / a))
+	}
+}
+
+// premultiplyRow turns non-premultiplied RGBA pixels into premultiplied
+// ones, in place.
+func premultiplyRow(pix []byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		if a == 0xff {
+			continue
+		}
+
+		pix[i+0] = uint8((uint32(pix[i+0])*a + 0x7f) / 0xff)
+		pix[i+1] = uint8((uint32(pix[i+1])*a + 0x7f) / 0xff)
+		pix[i+2] = uint8((uint32(pix[i+2])*a + 0x7f) / 0xff)
+	}
+}
+
+// min255 clamps the color channels of malformed premultiplied pixels,
+// which can be brighter than their alpha.
+func min255(v uint32) uint32 {
+	if v > 0xff {
+		return 0xff
+	}
+
+	return v
 }
 
 func (f File) Version() Version {
@@ -121,6 +443,52 @@ func (id ImageDescriptor) ImageOrigin() ImageOrigin {
 	}
 }
 
+// Interleave is the order scan lines are stored in, bits 6-7 of
+// ImageDescriptor. Only the original format defines it.
+type Interleave int
+
+const (
+	NonInterleaved Interleave = iota
+	TwoWayInterleaved
+	FourWayInterleaved
+	ReservedInterleave
+)
+
+func (i Interleave) String() string {
+	return [...]string{"NonInterleaved", "TwoWayInterleaved", "FourWayInterleaved", "ReservedInterleave"}[i]
+}
+
+// ways returns the number of passes the scan lines are stored in.
+func (i Interleave) ways() int {
+	switch i {
+	case TwoWayInterleaved:
+		return 2
+	case FourWayInterleaved:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// SetImageOrigin sets bits 4-5.
+func (id *ImageDescriptor) SetImageOrigin(o ImageOrigin) {
+	*id = *id&^48 | ImageDescriptor(o&3)<<4
+}
+
+// SetAlphaBits sets bits 0-3, the number of attribute bits per pixel.
+func (id *ImageDescriptor) SetAlphaBits(n int) {
+	*id = *id&^0x0f | ImageDescriptor(n&0x0f)
+}
+
+func (id ImageDescriptor) Interleave() Interleave {
+	return Interleave(id >> 6)
+}
+
+// AlphaBits returns the number of attribute bits per pixel, bits 0-3.
+func (id ImageDescriptor) AlphaBits() int {
+	return int(id & 0x0f)
+}
+
 type Header struct {
 	IDLength        byte            // byte
 	ColorMapType    byte            // byte
@@ -136,10 +504,132 @@ type Header struct {
 	ImageDescriptor ImageDescriptor // byte
 }
 
+// Rect returns the bounds of the image, always starting at (0, 0).
 func (h Header) Rect() image.Rectangle {
 	return image.Rect(0, 0, int(h.Width), int(h.Height))
 }
 
+// Origin returns XOrigin and YOrigin, the screen position of the image.
+func (h Header) Origin() image.Point {
+	return image.Pt(int(h.XOrigin), int(h.YOrigin))
+}
+
+// NewHeader returns a valid header for a true-color or grayscale image
+// covering rect, whose Min is stored as XOrigin and YOrigin.
+func NewHeader(rect image.Rectangle, imageType ImageType, bitsPerPixel int, origin ImageOrigin, alphaBits int) (Header, error) {
+	switch imageType {
+	case UncompressedRGBImage, UncompressedGrayscaleImage, RunLengthEncodedRGBImage, RunLengthEncodedGrayscaleImage:
+	default:
+		return Header{}, fmt.Errorf("tga.NewHeader: image type '%d' not supported", imageType)
+	}
+
+	if rect.Min.X < 0 || rect.Min.Y < 0 || rect.Min.X > math.MaxUint16 || rect.Min.Y > math.MaxUint16 {
+		return Header{}, fmt.Errorf("tga.NewHeader: origin %v doesn't fit in the header", rect.Min)
+	}
+
+	if rect.Dx() > math.MaxUint16 || rect.Dy() > math.MaxUint16 {
+		return Header{}, fmt.Errorf("tga.NewHeader: size %v doesn't fit in the header", rect.Size())
+	}
+
+	if bitsPerPixel < 0 || bitsPerPixel > math.MaxUint8 || alphaBits < 0 || alphaBits > 0x0f {
+		return Header{}, fmt.Errorf("tga.NewHeader: %d bits per pixel with %d alpha bits not allowed", bitsPerPixel, alphaBits)
+	}
+
+	h := Header{
+		ImageType:    imageType,
+		XOrigin:      uint16(rect.Min.X),
+		YOrigin:      uint16(rect.Min.Y),
+		Width:        uint16(rect.Dx()),
+		Height:       uint16(rect.Dy()),
+		BitsPerPixel: byte(bitsPerPixel),
+	}
+
+	h.ImageDescriptor.SetImageOrigin(origin)
+	h.ImageDescriptor.SetAlphaBits(alphaBits)
+
+	err := h.Validate()
+	if err != nil {
+		return Header{}, fmt.Errorf("tga.NewHeader: %v", err)
+	}
+
+	return h, nil
+}
+
+// Validate checks h against the specification: the image type, color map
+// and pixel depth must agree, alpha bits must fit in a pixel, the image
+// can't be empty and the reserved descriptor bits must be clear.
+func (h Header) Validate() error {
+	colorMapped := h.ImageType == UncompressedColorMappedImage || h.ImageType == RunLengthEncodedColorMappedImage
+	gray := h.ImageType == UncompressedGrayscaleImage || h.ImageType == RunLengthEncodedGrayscaleImage
+
+	switch h.ImageType {
+	case NoImage, UncompressedColorMappedImage, UncompressedRGBImage, UncompressedGrayscaleImage,
+		RunLengthEncodedColorMappedImage, RunLengthEncodedRGBImage, RunLengthEncodedGrayscaleImage:
+	default:
+		return fmt.Errorf("invalid header: unknown image type '%d'", h.ImageType)
+	}
+
+	switch {
+	case h.ColorMapType > 1:
+		return fmt.Errorf("invalid header: unknown color map type '%d'", h.ColorMapType)
+	case colorMapped && !h.HasColorMap():
+		return fmt.Errorf("invalid header: image type '%d' needs a color map", h.ImageType)
+	case !h.HasColorMap() && (h.ColorMapLength != 0 || h.ColorMapDepth != 0):
+		return fmt.Errorf("invalid header: color map specification without a color map")
+	}
+
+	if h.HasColorMap() {
+		switch h.ColorMapDepth {
+		case 15, Targa16, Targa24, Targa32:
+		default:
+			return fmt.Errorf("invalid header: color map depth '%d' not allowed", h.ColorMapDepth)
+		}
+	}
+
+	if h.ImageType == NoImage {
+		return nil
+	}
+
+	// alpha bits are stored in the color map entries of color-mapped images
+	depth := int(h.BitsPerPixel)
+
+	switch {
+	case colorMapped:
+		if depth != 8 && depth != 16 {
+			return fmt.Errorf("invalid header: bits per pixel '%d' not allowed for image type '%d'", depth, h.ImageType)
+		}
+
+		depth = int(h.ColorMapDepth)
+	case gray:
+		if depth != 8 && depth != 16 {
+			return fmt.Errorf("invalid header: bits per pixel '%d' not allowed for image type '%d'", depth, h.ImageType)
+		}
+	default:
+		if depth != 15 && depth != 16 && depth != 24 && depth != 32 {
+			return fmt.Errorf("invalid header: bits per pixel '%d' not allowed for image type '%d'", depth, h.ImageType)
+		}
+	}
+
+	maxAlphaBits := map[int]int{8: 0, 15: 0, 16: 1, 24: 0, 32: 8}[depth]
+	if gray {
+		maxAlphaBits = depth - 8
+	}
+
+	if h.ImageDescriptor.AlphaBits() > maxAlphaBits {
+		return fmt.Errorf("invalid header: %d alpha bits don't fit in %d bits per pixel", h.ImageDescriptor.AlphaBits(), depth)
+	}
+
+	if h.Width == 0 || h.Height == 0 {
+		return fmt.Errorf("invalid header: image is %dx%d", h.Width, h.Height)
+	}
+
+	if h.ImageDescriptor.Interleave() == ReservedInterleave {
+		return fmt.Errorf("invalid header: reserved image descriptor bits are set")
+	}
+
+	return nil
+}
+
 func (h Header) HasImageIDField() bool {
 	return h.IDLength > 0
 }
@@ -148,8 +638,18 @@ func (h Header) HasColorMap() bool {
 	return h.ColorMapType == 1
 }
 
+// colorMapBytes is the size of the color map, which true-color images may
+// have too.
+func (h Header) colorMapBytes() int {
+	if !h.HasColorMap() {
+		return 0
+	}
+
+	return int(h.ColorMapLength) * ((int(h.ColorMapDepth) + 7) / 8)
+}
+
 func (h Header) BytesPerPixel() int {
-	return int(h.BitsPerPixel) / 8
+	return (int(h.BitsPerPixel) + 7) / 8
 }
 
 func (h Header) ImageBytes() int {
@@ -204,14 +704,17 @@ func newSection(length int, offset int, whence int) sectionConfig {
 	}
 }
 
+const (
+	headerLen = 18
+	footerLen = 26
+)
+
 var (
-	headerSection = newSection(18, 0, io.SeekStart)
-	footerSection = newSection(26, -26, io.SeekEnd)
+	headerSection = newSection(headerLen, 0, io.SeekStart)
+	footerSection = newSection(footerLen, -footerLen, io.SeekEnd)
 )
 
 func read(rs io.ReadSeeker, config sectionConfig, data any) error {
-	r := bytes.NewBuffer(nil)
-
 	_, err := rs.Seek(config.offset, int(config.whence))
 	if err != nil {
 		return fmt.Errorf("failed to seek file: %v", err)
@@ -224,31 +727,130 @@ func read(rs io.ReadSeeker, config sectionConfig, data any) error {
 		}
 	}()
 
-	_, err = io.CopyN(r, rs, config.length)
-	if err != nil {
-		return fmt.Errorf("failed to io.CopyN bytes: %v", err)
+	r := io.LimitReader(rs, config.length)
+
+	// binary.Read would copy through a buffer of its own
+	if data, ok := data.([]byte); ok {
+		_, err = io.ReadFull(r, data)
+		return err
 	}
 
 	return binary.Read(r, binary.LittleEndian, data)
 }
 
+// BrokenSection is an optional section of a file that failed to parse.
+type BrokenSection struct {
+	Name string // the field of File it is read into
+	Err  error
+}
+
+// SectionsError is returned by Read along with a File holding everything but
+// the optional sections that failed to parse, which are left out. Developer
+// tags whose data failed to be read are kept without it.
+type SectionsError []BrokenSection
+
+func (e SectionsError) Error() string {
+	msgs := make([]string, len(e))
+	for i, s := range e {
+		msgs[i] = fmt.Sprintf("failed to read binary data into %s: %v", s.Name, s.Err)
+	}
+
+	return "tga.Read: " + strings.Join(msgs, "; ")
+}
+
+// Read reads the whole file in rs. Broken optional sections don't stop the
+// pixels from being read, they are told by a SectionsError.
 func Read(rs io.ReadSeeker) (File, error) {
-	var file File
+	file, broken, err := readMetadata(rs)
+	if err != nil {
+		return file, fmt.Errorf("tga.Read: %v", err)
+	}
+
+	err = readDeveloperData(rs, file.size, file.DeveloperTags)
+	if err != nil {
+		broken = append(broken, BrokenSection{Name: "DeveloperTags", Err: err})
+	}
+
+	// Read ImageData (CopyN of Header.Width * Header.Height)
+	if isRLE(file.Header) {
+		file.Image.Data, err = file.expandImage(rs)
+	} else {
+		file.Image.Data = make([]byte, file.Header.ImageBytes())
+		err = read(rs, newSection(len(file.Image.Data), int(file.dataOffset()), io.SeekStart), file.Image.Data)
+	}
+	if err != nil {
+		return file, fmt.Errorf("tga.Read: failed to read binary data info Image.Data: %v", err)
+	}
+
+	if len(broken) > 0 {
+		return file, broken
+	}
 
-	err := read(rs, footerSection, &file.Footer)
+	return file, nil
+}
+
+// Open parses the header, footer and extension area of the file in ra, of
+// size bytes, leaving Image.Data empty. Pixels are then read on demand by
+// PixelAt and At, so ra must stay readable while the File is used. Optional
+// sections that fail to parse are left out, and developer tags have no data.
+// Run-length encoded pixels can't be read one by one, they are all expanded
+// the first time one is needed, and broken packets only show then.
+func Open(ra io.ReaderAt, size int64) (File, error) {
+	file, _, err := readMetadata(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return file, fmt.Errorf("tga.Open: %v", err)
+	}
+
+	if isRLE(file.Header) {
+		err = file.checkPacked()
+		if err != nil {
+			return file, fmt.Errorf("tga.Open: %v", err)
+		}
+
+		file.rle = &expansion{}
+	} else if file.dataOffset()+int64(file.Header.ImageBytes()) > size {
+		return file, fmt.Errorf("tga.Open: image data runs past the end of the file")
+	}
+
+	file.src = ra
+
+	return file, nil
+}
+
+// readMetadata reads every section of the file but the image data and the
+// data of the developer tags. Broken optional sections are left out and
+// returned apart.
+func readMetadata(rs io.ReadSeeker) (File, SectionsError, error) {
+	var (
+		file   File
+		broken SectionsError
+	)
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return file, nil, fmt.Errorf("failed to seek file: %v", err)
+	}
+
+	_, err = rs.Seek(0, io.SeekStart)
 	if err != nil {
-		return file, fmt.Errorf("tga.Read: failed to read binary data into Footer: %v", err)
+		return file, nil, fmt.Errorf("failed to seek file: %v", err)
+	}
+
+	file.size = size
+
+	err = read(rs, footerSection, &file.Footer)
+	if err != nil {
+		return file, nil, fmt.Errorf("failed to read binary data into Footer: %v", err)
 	}
 
 	err = read(rs, headerSection, &file.Header)
 	if err != nil {
-		return file, fmt.Errorf("tga.Read: failed to read binary data into Header: %v", err)
+		return file, nil, fmt.Errorf("failed to read binary data into Header: %v", err)
 	}
 
 	file.Image = Image{
 		ID:       make([]byte, file.Header.IDLength),
-		ColorMap: []byte{},
-		Data:     make([]byte, file.Header.ImageBytes()),
+		ColorMap: make([]byte, file.Header.colorMapBytes()),
 	}
 
 	// Read ImageID (CopyN of Header.IDLength)
@@ -256,20 +858,40 @@ func Read(rs io.ReadSeeker) (File, error) {
 		newSection(len(file.Image.ID), int(headerSection.length), io.SeekStart),
 		file.Image.ID)
 	if err != nil {
-		return file, fmt.Errorf("tga.Read: failed to read binary data into ImageData: %v", err)
+		return file, nil, fmt.Errorf("failed to read binary data into ImageData: %v", err)
 	}
 
-	// Read ColorMapData (CopyN of ???)
-
-	// Read ImageData (CopyN of Header.Width * Header.Height)
 	err = read(rs,
-		newSection(len(file.Image.Data), int(headerSection.length)+len(file.Image.ID), io.SeekStart),
-		file.Image.Data)
+		newSection(len(file.Image.ColorMap), int(headerSection.length)+len(file.Image.ID), io.SeekStart),
+		file.Image.ColorMap)
 	if err != nil {
-		return file, fmt.Errorf("tga.Read: failed to read binary data info Image.Data: %v", err)
+		return file, nil, fmt.Errorf("failed to read binary data into ColorMap: %v", err)
 	}
 
-	return file, err
+	// the sections below are optional, those failing to parse are left out
+	// instead of failing the whole file
+	optional := func(name string, err error) {
+		if err != nil {
+			broken = append(broken, BrokenSection{Name: name, Err: err})
+		}
+	}
+
+	file.ExtensionArea, err = readExtensionArea(rs, file.Footer)
+	optional("ExtensionArea", err)
+
+	file.ColorCorrectionTable, err = readColorCorrectionTable(rs, file.ExtensionArea)
+	optional("ColorCorrectionTable", err)
+
+	file.ScanLineTable, err = readScanLineTable(rs, file.Header, file.ExtensionArea)
+	optional("ScanLineTable", err)
+
+	file.DeveloperTags, err = readDeveloperDirectory(rs, file.Footer, file.size)
+	optional("DeveloperTags", err)
+
+	// only Layout needs the postage stamp size
+	file.stampSize = readStampSize(rs, file.ExtensionArea)
+
+	return file, broken, nil
 }
 
 // from: http://www.paulbourke.net/dataformats/tga/
@@ -2,6 +2,10 @@ package tga
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
 	"os"
 	"reflect"
 	"testing"
@@ -45,6 +49,7 @@ func TestRead(t *testing.T) {
 					Point:                    '.',
 					End:                      0x00,
 				},
+				size: 46,
 			},
 		},
 	}
@@ -250,6 +255,198 @@ func TestPixelPosition(t *testing.T) {
 	}
 }
 
+func TestNewHeader(t *testing.T) {
+	testCases := []struct {
+		rect         image.Rectangle
+		imageType    ImageType
+		bitsPerPixel int
+		origin       ImageOrigin
+		alphaBits    int
+		expected     Header
+		valid        bool
+	}{
+		{
+			rect: image.Rect(0, 0, 64, 32), imageType: UncompressedRGBImage, bitsPerPixel: 32, origin: TopLeft, alphaBits: 8,
+			expected: Header{ImageType: UncompressedRGBImage, Width: 64, Height: 32, BitsPerPixel: 32, ImageDescriptor: 32 | 8},
+			valid:    true,
+		},
+		{
+			rect: image.Rect(10, 20, 13, 24), imageType: RunLengthEncodedRGBImage, bitsPerPixel: 16, origin: BottomRight, alphaBits: 1,
+			expected: Header{ImageType: RunLengthEncodedRGBImage, XOrigin: 10, YOrigin: 20, Width: 3, Height: 4, BitsPerPixel: 16, ImageDescriptor: 16 | 1},
+			valid:    true,
+		},
+		{
+			rect: image.Rect(0, 0, 8, 8), imageType: UncompressedGrayscaleImage, bitsPerPixel: 8, origin: BottomLeft, alphaBits: 0,
+			expected: Header{ImageType: UncompressedGrayscaleImage, Width: 8, Height: 8, BitsPerPixel: 8},
+			valid:    true,
+		},
+		{rect: image.Rect(0, 0, 8, 8), imageType: UncompressedRGBImage, bitsPerPixel: 24, origin: TopLeft, alphaBits: 8, valid: false},
+		{rect: image.Rect(0, 0, 8, 8), imageType: UncompressedColorMappedImage, bitsPerPixel: 8, origin: TopLeft, alphaBits: 0, valid: false},
+		{rect: image.Rect(-1, 0, 8, 8), imageType: UncompressedRGBImage, bitsPerPixel: 24, origin: TopLeft, alphaBits: 0, valid: false},
+		{rect: image.Rect(0, 0, 1<<16, 8), imageType: UncompressedRGBImage, bitsPerPixel: 24, origin: TopLeft, alphaBits: 0, valid: false},
+		{rect: image.Rect(0, 0, 0, 8), imageType: UncompressedRGBImage, bitsPerPixel: 24, origin: TopLeft, alphaBits: 0, valid: false},
+		{rect: image.Rect(0, 0, 8, 8), imageType: UncompressedRGBImage, bitsPerPixel: 32, origin: TopLeft, alphaBits: 16, valid: false},
+	}
+
+	for i, tc := range testCases {
+		got, err := NewHeader(tc.rect, tc.imageType, tc.bitsPerPixel, tc.origin, tc.alphaBits)
+
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("test %d: expected an error, but got %+v", i+1, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestImageDescriptorSetters(t *testing.T) {
+	testCases := []struct {
+		imageDescriptor ImageDescriptor
+		origin          ImageOrigin
+		alphaBits       int
+		expected        ImageDescriptor
+	}{
+		{imageDescriptor: 0, origin: TopLeft, alphaBits: 8, expected: 32 | 8},
+		{imageDescriptor: 48 | 8, origin: BottomLeft, alphaBits: 0, expected: 0},
+		{imageDescriptor: 64 | 32 | 1, origin: TopRight, alphaBits: 1, expected: 64 | 48 | 1},
+		{imageDescriptor: 15, origin: BottomRight, alphaBits: 4, expected: 16 | 4},
+	}
+
+	for i, tc := range testCases {
+		got := tc.imageDescriptor
+		got.SetImageOrigin(tc.origin)
+		got.SetAlphaBits(tc.alphaBits)
+
+		if got != tc.expected {
+			t.Errorf("test %d: expected %08b, but got %08b", i+1, tc.expected, got)
+		}
+
+		if got.ImageOrigin() != tc.origin || got.AlphaBits() != tc.alphaBits {
+			t.Errorf("test %d: expected `%s` with %d alpha bits, but got `%s` with %d", i+1, tc.origin, tc.alphaBits, got.ImageOrigin(), got.AlphaBits())
+		}
+	}
+}
+
+func TestHeaderValidate(t *testing.T) {
+	valid := Header{ImageType: UncompressedRGBImage, Width: 4, Height: 4, BitsPerPixel: 32, ImageDescriptor: 32 | 8}
+
+	with := func(fn func(h *Header)) Header {
+		h := valid
+		fn(&h)
+		return h
+	}
+
+	testCases := []struct {
+		header Header
+		valid  bool
+	}{
+		{header: valid, valid: true},
+		{header: with(func(h *Header) { h.ImageType = 4 }), valid: false},
+		{header: with(func(h *Header) { h.ImageType = NoImage; h.Width, h.Height, h.BitsPerPixel = 0, 0, 0 }), valid: true},
+		{header: with(func(h *Header) { h.ImageType = RunLengthEncodedRGBImage; h.BitsPerPixel = 24; h.ImageDescriptor = 0 }), valid: true},
+		{header: with(func(h *Header) { h.BitsPerPixel = 8 }), valid: false},
+		{header: with(func(h *Header) { h.BitsPerPixel = 24 }), valid: false},
+		{header: with(func(h *Header) { h.BitsPerPixel = 16; h.ImageDescriptor = 1 }), valid: true},
+		{header: with(func(h *Header) { h.BitsPerPixel = 16 }), valid: false},
+		{header: with(func(h *Header) { h.ImageDescriptor = 32 | 9 }), valid: false},
+		{header: with(func(h *Header) { h.ImageType = UncompressedGrayscaleImage; h.BitsPerPixel = 16 }), valid: true},
+		{header: with(func(h *Header) { h.ImageType = UncompressedGrayscaleImage; h.BitsPerPixel = 8 }), valid: false},
+		{header: with(func(h *Header) { h.ImageType = UncompressedColorMappedImage; h.BitsPerPixel = 8 }), valid: false},
+		{
+			header: with(func(h *Header) {
+				h.ImageType, h.BitsPerPixel = UncompressedColorMappedImage, 8
+				h.ColorMapType, h.ColorMapLength, h.ColorMapDepth = 1, 256, Targa32
+			}),
+			valid: true,
+		},
+		{
+			header: with(func(h *Header) {
+				h.ImageType, h.BitsPerPixel = UncompressedColorMappedImage, 8
+				h.ColorMapType, h.ColorMapLength, h.ColorMapDepth = 1, 256, 12
+			}),
+			valid: false,
+		},
+		{header: with(func(h *Header) { h.ColorMapType = 2 }), valid: false},
+		{header: with(func(h *Header) { h.ColorMapLength = 16 }), valid: false},
+		{header: with(func(h *Header) { h.Width = 0 }), valid: false},
+		{header: with(func(h *Header) { h.Height = 0 }), valid: false},
+		{header: with(func(h *Header) { h.ImageDescriptor |= 64 }), valid: true},
+		{header: with(func(h *Header) { h.ImageDescriptor |= 192 }), valid: false},
+	}
+
+	for i, tc := range testCases {
+		err := tc.header.Validate()
+
+		if tc.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if !tc.valid && err == nil {
+			t.Errorf("test %d: expected an error for %+v", i+1, tc.header)
+		}
+	}
+}
+
+func TestImageDescriptorBits(t *testing.T) {
+	testCases := []struct {
+		imageDescriptor ImageDescriptor
+		interleave      Interleave
+		alphaBits       int
+	}{
+		{imageDescriptor: 0, interleave: NonInterleaved, alphaBits: 0},
+		{imageDescriptor: 32 | 8, interleave: NonInterleaved, alphaBits: 8},
+		{imageDescriptor: 64 | 1, interleave: TwoWayInterleaved, alphaBits: 1},
+		{imageDescriptor: 128 | 48, interleave: FourWayInterleaved, alphaBits: 0},
+		{imageDescriptor: 255, interleave: ReservedInterleave, alphaBits: 15},
+	}
+
+	for i, tc := range testCases {
+		if got := tc.imageDescriptor.Interleave(); got != tc.interleave {
+			t.Errorf("test %d: expected `%s`, but got `%s`", i+1, tc.interleave, got)
+		}
+
+		if got := tc.imageDescriptor.AlphaBits(); got != tc.alphaBits {
+			t.Errorf("test %d: expected %d alpha bits, but got %d", i+1, tc.alphaBits, got)
+		}
+	}
+}
+
+func TestStoredRow(t *testing.T) {
+	testCases := []struct {
+		imageDescriptor ImageDescriptor
+		height          uint16
+		expected        []int
+	}{
+		{imageDescriptor: 32, height: 5, expected: []int{0, 1, 2, 3, 4}},
+		{imageDescriptor: 0, height: 5, expected: []int{4, 3, 2, 1, 0}},
+		{imageDescriptor: 64 | 32, height: 5, expected: []int{0, 3, 1, 4, 2}},
+		{imageDescriptor: 64, height: 5, expected: []int{2, 4, 1, 3, 0}},
+		{imageDescriptor: 128 | 32, height: 6, expected: []int{0, 2, 4, 5, 1, 3}},
+	}
+
+	for i, tc := range testCases {
+		header := Header{Height: tc.height, ImageDescriptor: tc.imageDescriptor}
+
+		got := make([]int, tc.height)
+		for y := range got {
+			got[y] = storedRow(header, y)
+		}
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
 func TestPixelAt(t *testing.T) {
 	testCases := []struct {
 		img      File
@@ -420,3 +617,406 @@ func TestPixelAt(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkFileRGBA(b *testing.B) {
+	for _, bitsPerPixel := range []byte{16, 24, 32} {
+		header := Header{
+			ImageType:    UncompressedRGBImage,
+			Width:        1024,
+			Height:       1024,
+			BitsPerPixel: bitsPerPixel,
+		}
+
+		file, err := Read(bytes.NewReader(newBenchmarkFile(header)))
+		if err != nil {
+			b.Fatalf("failed to Read file: %v", err)
+		}
+
+		b.Run(fmt.Sprintf("Targa%d", bitsPerPixel), func(b *testing.B) {
+			b.SetBytes(int64(header.ImageBytes()))
+
+			for i := 0; i < b.N; i++ {
+				file.RGBA()
+			}
+		})
+	}
+}
+
+func TestSwizzleRow(t *testing.T) {
+	testCases := []struct {
+		src           []byte
+		bytesPerPixel int
+		reverse       bool
+		alpha         bool
+		expected      []byte
+	}{
+		{
+			src:           []byte{0x1f, 0x7c, 0xe0, 0x03},
+			bytesPerPixel: 2,
+			expected:      []byte{255, 0, 255, 255, 0, 255, 0, 255},
+		},
+		{
+			src:           []byte{1, 2, 3, 4, 5, 6},
+			bytesPerPixel: 3,
+			expected:      []byte{3, 2, 1, 255, 6, 5, 4, 255},
+		},
+		{
+			src:           []byte{1, 2, 3, 4, 5, 6},
+			bytesPerPixel: 3,
+			reverse:       true,
+			expected:      []byte{6, 5, 4, 255, 3, 2, 1, 255},
+		},
+		{
+			src:           []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			bytesPerPixel: 4,
+			expected:      []byte{3, 2, 1, 255, 7, 6, 5, 255},
+		},
+		{
+			src:           []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			bytesPerPixel: 4,
+			alpha:         true,
+			expected:      []byte{3, 2, 1, 4, 7, 6, 5, 8},
+		},
+		{
+			src:           []byte{0x1f, 0x7c, 0xe0, 0x83},
+			bytesPerPixel: 2,
+			alpha:         true,
+			expected:      []byte{255, 0, 255, 0, 0, 255, 0, 255},
+		},
+	}
+
+	for i, tc := range testCases {
+		got := make([]byte, len(tc.expected))
+		swizzleRow(got, tc.src, tc.bytesPerPixel, tc.reverse, tc.alpha)
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, filename := range testFiles {
+		data, err := os.ReadFile("./testdata/" + filename)
+		if err != nil {
+			t.Fatalf("%s: failed to open test file: %v", filename, err)
+		}
+
+		read, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: failed to Read file: %v", filename, err)
+		}
+
+		decoded, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", filename, err)
+		}
+
+		opened, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: failed to Open file: %v", filename, err)
+		}
+
+		if opened.Image.Data != nil {
+			t.Errorf("%s: expected Open not to read the image data", filename)
+		}
+
+		if !reflect.DeepEqual(read.Header, opened.Header) || !reflect.DeepEqual(read.Footer, opened.Footer) {
+			t.Errorf("%s: expected the same header and footer as Read", filename)
+		}
+
+		bounds := opened.Bounds()
+
+		for _, p := range []image.Point{{0, 0}, {bounds.Dx() / 2, bounds.Dy() / 3}, bounds.Max.Sub(image.Pt(1, 1))} {
+			if !reflect.DeepEqual(read.PixelAt(p.X, p.Y), opened.PixelAt(p.X, p.Y)) {
+				t.Errorf("%s: PixelAt%v: expected `%v`, but got `%v`", filename, p, read.PixelAt(p.X, p.Y), opened.PixelAt(p.X, p.Y))
+			}
+
+			if !reflect.DeepEqual(decoded.At(p.X, p.Y), opened.At(p.X, p.Y)) {
+				t.Errorf("%s: At%v: expected `%v`, but got `%v`", filename, p, decoded.At(p.X, p.Y), opened.At(p.X, p.Y))
+			}
+		}
+	}
+}
+
+func TestColorMapSkipped(t *testing.T) {
+	header := Header{
+		ImageType:       UncompressedRGBImage,
+		ColorMapType:    1,
+		ColorMapLength:  1,
+		ColorMapDepth:   Targa24,
+		Width:           1,
+		Height:          1,
+		BitsPerPixel:    24,
+		ImageDescriptor: 32,
+	}
+
+	data := newTestFile(t, header, []byte{9, 9, 9, 1, 2, 3}, nil, nil, false)
+	expected := color.RGBA{R: 3, G: 2, B: 1, A: 255}
+
+	decoded, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	read, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to Read file: %v", err)
+	}
+
+	opened, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to Open file: %v", err)
+	}
+
+	rr, err := NewRowReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row := make([]byte, 4)
+	if err := rr.ReadRow(row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal([]byte{9, 9, 9}, read.Image.ColorMap) {
+		t.Errorf("expected color map `%v`, but got `%v`", []byte{9, 9, 9}, read.Image.ColorMap)
+	}
+
+	got := []color.Color{decoded.At(0, 0), read.At(0, 0), opened.At(0, 0), color.RGBA{R: row[0], G: row[1], B: row[2], A: row[3]}}
+	for i, c := range got {
+		if c != expected {
+			t.Errorf("test %d: expected %v, but got %v", i+1, expected, c)
+		}
+	}
+
+	for _, s := range read.Layout() {
+		if s.Name == "pixel data" && s.Offset != read.dataOffset() {
+			t.Errorf("expected pixel data at %d, but Layout puts it at %d", read.dataOffset(), s.Offset)
+		}
+	}
+}
+
+func TestOpenTruncated(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 4, Height: 4, BitsPerPixel: 24}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write(make([]byte, 10))
+	binary.Write(&buf, binary.LittleEndian, Footer{})
+
+	_, err := Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err == nil {
+		t.Errorf("expected an error for image data running past the end of the file")
+	}
+}
+
+func TestFileDecode(t *testing.T) {
+	inputs := map[string][]byte{}
+
+	for _, filename := range testFiles {
+		data, err := os.ReadFile("./testdata/" + filename)
+		if err != nil {
+			t.Fatalf("%s: failed to open test file: %v", filename, err)
+		}
+
+		inputs[filename] = data
+	}
+
+	for _, imageDescriptor := range []ImageDescriptor{8, 16 | 8, 32 | 8, 48 | 8} {
+		header := Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 32, ImageDescriptor: imageDescriptor}
+		data := []byte{1, 2, 3, 255, 4, 5, 6, 128, 7, 8, 9, 0, 10, 11, 12, 64}
+
+		inputs[header.ImageDescriptor.ImageOrigin().String()] = newTestFile(t, header, data, nil, nil, false)
+	}
+
+	for name, data := range inputs {
+		expected, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		read, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: failed to Read file: %v", name, err)
+		}
+
+		opened, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: failed to Open file: %v", name, err)
+		}
+
+		for _, file := range []File{read, opened} {
+			got, err := file.Decode()
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("%s: expected File.Decode to match Decode", name)
+			}
+
+			rgba := image.NewRGBA(expected.Bounds())
+			for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+				for x := rgba.Rect.Min.X; x < rgba.Rect.Max.X; x++ {
+					rgba.Set(x, y, expected.At(x, y))
+				}
+			}
+
+			if !reflect.DeepEqual(rgba, file.RGBA()) {
+				t.Errorf("%s: expected File.RGBA to match Decode", name)
+			}
+		}
+	}
+}
+
+func TestFileDecodeMissingData(t *testing.T) {
+	file := File{Header: Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24}}
+
+	_, err := file.Decode()
+	if err == nil {
+		t.Errorf("expected an error for a file without image data")
+	}
+}
+
+func TestColorAt(t *testing.T) {
+	testCases := []struct {
+		header   Header
+		data     []byte
+		expected color.Color
+	}{
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24},
+			data:     []byte{1, 2, 3},
+			expected: BGR888{B: 1, G: 2, R: 3},
+		},
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 32, ImageDescriptor: 8},
+			data:     []byte{1, 2, 3, 4},
+			expected: BGRA8888{B: 1, G: 2, R: 3, A: 4},
+		},
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 16},
+			data:     []byte{0x1f, 0x7c},
+			expected: ARGB1555(0xfc1f),
+		},
+		{
+			header:   Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 16, ImageDescriptor: 1},
+			data:     []byte{0x1f, 0x7c},
+			expected: ARGB1555(0x7c1f),
+		},
+		{
+			header:   Header{ImageType: UncompressedGrayscaleImage, Width: 1, Height: 1, BitsPerPixel: 8},
+			data:     []byte{7},
+			expected: color.Gray{Y: 7},
+		},
+		{
+			header:   Header{ImageType: UncompressedGrayscaleImage, Width: 1, Height: 1, BitsPerPixel: 16, ImageDescriptor: 8},
+			data:     []byte{7, 8},
+			expected: GrayAlpha{Y: 7, A: 8},
+		},
+	}
+
+	for i, tc := range testCases {
+		f := File{Header: tc.header, Image: Image{Data: tc.data}}
+
+		if got := f.ColorAt(0, 0); tc.expected != got {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestEach(t *testing.T) {
+	// stored as 1, 2 in the first row and 3, 4 in the second one
+	data := []byte{1, 1, 1, 2, 2, 2, 3, 3, 3, 4, 4, 4}
+
+	testCases := []struct {
+		imageDescriptor ImageDescriptor
+		expected        []byte // blue channel of the pixels, in display order
+	}{
+		{imageDescriptor: 0, expected: []byte{3, 4, 1, 2}},
+		{imageDescriptor: 16, expected: []byte{4, 3, 2, 1}},
+		{imageDescriptor: 32, expected: []byte{1, 2, 3, 4}},
+		{imageDescriptor: 48, expected: []byte{2, 1, 4, 3}},
+	}
+
+	for i, tc := range testCases {
+		f := File{
+			Header: Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24, ImageDescriptor: tc.imageDescriptor},
+			Image:  Image{Data: data},
+		}
+
+		var got []byte
+		var points []image.Point
+
+		f.Each(func(x, y int, c color.Color) bool {
+			got = append(got, c.(BGR888).B)
+			points = append(points, image.Pt(x, y))
+			return true
+		})
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+
+		expectedPoints := []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+		if !reflect.DeepEqual(expectedPoints, points) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, expectedPoints, points)
+		}
+	}
+}
+
+func TestEachStops(t *testing.T) {
+	f := File{
+		Header: Header{ImageType: UncompressedRGBImage, Width: 2, Height: 2, BitsPerPixel: 24},
+		Image:  Image{Data: make([]byte, 12)},
+	}
+
+	calls := 0
+	f.Each(func(x, y int, c color.Color) bool {
+		calls++
+		return calls < 3
+	})
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls, but got %d", calls)
+	}
+}
+
+func TestIterOpen(t *testing.T) {
+	data, err := os.ReadFile("./testdata/flag_t16.tga")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+
+	read, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to Read file: %v", err)
+	}
+
+	opened, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to Open file: %v", err)
+	}
+
+	a, b := read.Iter(), opened.Iter()
+	n := 0
+
+	for a.Next() {
+		if !b.Next() {
+			t.Fatalf("expected as many pixels from Open as from Read")
+		}
+
+		ax, ay, ac := a.Pixel()
+		bx, by, bc := b.Pixel()
+		if ax != bx || ay != by || ac != bc {
+			t.Fatalf("pixel %d: expected (%d, %d, %v), but got (%d, %d, %v)", n, ax, ay, ac, bx, by, bc)
+		}
+
+		n++
+	}
+
+	if n != 124*124 {
+		t.Errorf("expected %d pixels, but got %d", 124*124, n)
+	}
+}
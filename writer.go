@@ -0,0 +1,405 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+type EncodeOptions struct {
+	// ImageID is written right after the header, Header.IDLength is set from
+	// its length.
+	ImageID []byte
+
+	// ExtensionArea is written after the image data. The writer fills in
+	// the size and offsets of a copy of it, and the attributes type when it
+	// is left unset for an image with alpha bits.
+	ExtensionArea *ExtensionArea
+
+	// ScanLineTable writes the offset of every stored row after the image
+	// data, and records it in the extension area, creating an ExtensionArea
+	// when there is none.
+	ScanLineTable bool
+
+	// Premultiplied stores colors premultiplied by alpha and records it in
+	// the extension area attributes type, creating an ExtensionArea when
+	// there is none.
+	Premultiplied bool
+
+	// PostageStamp writes a copy of the image downsampled to at most 64x64
+	// pixels, in the same pixel format, and records its offset in the
+	// extension area, creating an ExtensionArea when there is none.
+	PostageStamp bool
+}
+
+// maxPostageStamp is the largest width and height of a postage stamp.
+const maxPostageStamp = 64
+
+// RowWriter writes an image one row at a time, as raw or run-length encoded
+// packets depending on Header.ImageType, so the whole image never has to be
+// held in memory. Rows are written in the order they are stored: top-down
+// for top origins and bottom-up for bottom origins.
+type RowWriter struct {
+	w      io.Writer
+	header Header
+	opts   EncodeOptions
+
+	offset    int64 // bytes written so far
+	y         int
+	scanLines ScanLineTable
+	row       []byte // stored pixels of the current row
+	packets   []byte
+	premul    []byte // current row premultiplied by alpha
+	closed    bool
+
+	stamp                   []byte // postage stamp, stored like the image
+	stampWidth, stampHeight int
+}
+
+func NewRowWriter(w io.Writer, header Header, opts *EncodeOptions) (*RowWriter, error) {
+	rw := RowWriter{w: w, header: header}
+	if opts != nil {
+		rw.opts = *opts
+	}
+
+	if len(rw.opts.ImageID) > 255 {
+		return nil, fmt.Errorf("tga.NewRowWriter: image ID is %d bytes long, at most 255 are allowed", len(rw.opts.ImageID))
+	}
+
+	// Close fills in the extension area, the caller's one is left as is
+	if rw.opts.ExtensionArea != nil {
+		ext := *rw.opts.ExtensionArea
+		rw.opts.ExtensionArea = &ext
+	} else if rw.opts.ScanLineTable || rw.opts.Premultiplied || rw.opts.PostageStamp {
+		rw.opts.ExtensionArea = &ExtensionArea{}
+	}
+
+	switch header.ImageType {
+	case UncompressedRGBImage, RunLengthEncodedRGBImage:
+	default:
+		return nil, fmt.Errorf("tga.NewRowWriter: image type '%d' not supported", header.ImageType)
+	}
+
+	switch header.BitsPerPixel {
+	case 15, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("tga.NewRowWriter: bits per pixel '%d' not supported", header.BitsPerPixel)
+	}
+
+	// rows are written in the order they are stored
+	if i := header.ImageDescriptor.Interleave(); i != NonInterleaved {
+		return nil, fmt.Errorf("tga.NewRowWriter: interleave '%v' not supported", i)
+	}
+
+	rw.header.IDLength = byte(len(rw.opts.ImageID))
+	rw.header.ColorMapType = 0
+	rw.header.ColorMapOrigin, rw.header.ColorMapLength, rw.header.ColorMapDepth = 0, 0, 0
+
+	err := rw.header.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("tga.NewRowWriter: %v", err)
+	}
+
+	rw.row = make([]byte, int(header.Width)*header.BytesPerPixel())
+
+	if rw.opts.PostageStamp {
+		rw.stampWidth, rw.stampHeight = postageStampSize(int(header.Width), int(header.Height))
+		rw.stamp = make([]byte, rw.stampWidth*rw.stampHeight*header.BytesPerPixel())
+	}
+
+	err = rw.write(rw.header)
+	if err != nil {
+		return nil, fmt.Errorf("tga.NewRowWriter: failed to write Header: %v", err)
+	}
+
+	err = rw.write(rw.opts.ImageID)
+	if err != nil {
+		return nil, fmt.Errorf("tga.NewRowWriter: failed to write image ID: %v", err)
+	}
+
+	return &rw, nil
+}
+
+// WriteRow converts the next row, given as non-premultiplied RGBA pixels,
+// into the pixel format of the header and writes it.
+func (rw *RowWriter) WriteRow(row []byte) error {
+	if rw.closed {
+		return fmt.Errorf("tga.RowWriter: write after Close")
+	}
+
+	if rw.y >= int(rw.header.Height) {
+		return fmt.Errorf("tga.RowWriter: all %d rows were already written", rw.header.Height)
+	}
+
+	if len(row) < int(rw.header.Width)*4 {
+		return fmt.Errorf("tga.RowWriter: row needs %d bytes, got %d", int(rw.header.Width)*4, len(row))
+	}
+
+	row = row[:int(rw.header.Width)*4]
+
+	if rw.opts.Premultiplied {
+		rw.premul = append(rw.premul[:0], row...)
+		premultiplyRow(rw.premul)
+		row = rw.premul
+	}
+
+	origin := rw.header.ImageDescriptor.ImageOrigin()
+	unswizzleRow(rw.row, row, rw.header.BytesPerPixel(), origin == BottomRight || origin == TopRight)
+
+	if rw.stamp != nil {
+		rw.sampleStamp()
+	}
+
+	if rw.opts.ScanLineTable {
+		rw.scanLines = append(rw.scanLines, uint32(rw.offset))
+	}
+
+	data := rw.row
+	if rw.header.ImageType == RunLengthEncodedRGBImage {
+		rw.packets = encodeRLE(rw.packets[:0], rw.row, rw.header.BytesPerPixel())
+		data = rw.packets
+	}
+
+	err := rw.write(data)
+	if err != nil {
+		return fmt.Errorf("tga.RowWriter: failed to write row %d: %v", rw.y, err)
+	}
+
+	rw.y++
+
+	return nil
+}
+
+// Close writes the postage stamp, the scan line table, the extension area
+// and the footer. It fails when not every row was written. The underlying
+// writer isn't closed.
+func (rw *RowWriter) Close() error {
+	if rw.closed {
+		return nil
+	}
+
+	if rw.y != int(rw.header.Height) {
+		return fmt.Errorf("tga.RowWriter: only %d of %d rows were written", rw.y, rw.header.Height)
+	}
+
+	rw.closed = true
+
+	footer := Footer{Point: '.'}
+	copy(footer.Signature[:], "TRUEVISION-XFILE")
+
+	if ext := rw.opts.ExtensionArea; ext != nil {
+		ext.ExtensionSize = extensionAreaLen
+		ext.ColorCorrectionOffset = 0
+		ext.PostageStampOffset = 0
+		ext.ScanLineOffset = 0
+
+		if rw.opts.Premultiplied {
+			ext.AttributesType = PremultipliedAlpha
+		} else if ext.AttributesType == NoAlpha && rw.header.ImageDescriptor.AlphaBits() > 0 {
+			// readers ignore alpha when an extension area doesn't tell
+			// it is useful
+			ext.AttributesType = UsefulAlpha
+		}
+
+		if rw.stamp != nil {
+			ext.PostageStampOffset = uint32(rw.offset)
+
+			err := rw.write([]byte{byte(rw.stampWidth), byte(rw.stampHeight)})
+			if err == nil {
+				err = rw.write(rw.stamp)
+			}
+			if err != nil {
+				return fmt.Errorf("tga.RowWriter: failed to write postage stamp: %v", err)
+			}
+		}
+
+		if rw.opts.ScanLineTable {
+			ext.ScanLineOffset = uint32(rw.offset)
+
+			err := rw.write(rw.scanLines)
+			if err != nil {
+				return fmt.Errorf("tga.RowWriter: failed to write ScanLineTable: %v", err)
+			}
+		}
+
+		footer.ExtensionAreaOffset = uint32(rw.offset)
+
+		err := rw.write(ext)
+		if err != nil {
+			return fmt.Errorf("tga.RowWriter: failed to write ExtensionArea: %v", err)
+		}
+	}
+
+	err := rw.write(footer)
+	if err != nil {
+		return fmt.Errorf("tga.RowWriter: failed to write Footer: %v", err)
+	}
+
+	return nil
+}
+
+// sampleStamp copies the pixels of the current stored row that are nearest
+// to postage stamp pixels.
+func (rw *RowWriter) sampleStamp() {
+	bytesPerPixel := rw.header.BytesPerPixel()
+
+	for j := 0; j < rw.stampHeight; j++ {
+		if j*int(rw.header.Height)/rw.stampHeight != rw.y {
+			continue
+		}
+
+		dst := rw.stamp[j*rw.stampWidth*bytesPerPixel:]
+		for i := 0; i < rw.stampWidth; i++ {
+			x := i * int(rw.header.Width) / rw.stampWidth
+			copy(dst[i*bytesPerPixel:(i+1)*bytesPerPixel], rw.row[x*bytesPerPixel:])
+		}
+	}
+}
+
+// postageStampSize scales width and height down, keeping their ratio, until
+// both fit in maxPostageStamp.
+func postageStampSize(width, height int) (int, int) {
+	longest := width
+	if height > longest {
+		longest = height
+	}
+
+	if longest <= maxPostageStamp {
+		return width, height
+	}
+
+	width, height = width*maxPostageStamp/longest, height*maxPostageStamp/longest
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	return width, height
+}
+
+func (rw *RowWriter) write(data any) error {
+	if data, ok := data.([]byte); ok {
+		n, err := rw.w.Write(data)
+		rw.offset += int64(n)
+		return err
+	}
+
+	err := binary.Write(rw.w, binary.LittleEndian, data)
+	if err == nil {
+		rw.offset += int64(binary.Size(data))
+	}
+
+	return err
+}
+
+// Encode writes m as an uncompressed top-left Targa 24 image, or Targa 32
+// when m isn't opaque. Bounds().Min is stored as XOrigin and YOrigin.
+func Encode(w io.Writer, m image.Image, opts *EncodeOptions) error {
+	b := m.Bounds()
+
+	bitsPerPixel, alphaBits := 24, 0
+	if o, ok := m.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+		bitsPerPixel, alphaBits = 32, 8
+	}
+
+	header, err := NewHeader(b, UncompressedRGBImage, bitsPerPixel, TopLeft, alphaBits)
+	if err != nil {
+		return err
+	}
+
+	rw, err := NewRowWriter(w, header, opts)
+	if err != nil {
+		return err
+	}
+
+	row := make([]byte, b.Dx()*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x, i := b.Min.X, 0; x < b.Max.X; x, i = x+1, i+4 {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			row[i+0], row[i+1], row[i+2], row[i+3] = c.R, c.G, c.B, c.A
+		}
+
+		err = rw.WriteRow(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rw.Close()
+}
+
+// unswizzleRow converts a row of non-premultiplied RGBA pixels into stored
+// pixels (BGR, BGRA or ARGB1555). dst runs right-to-left when reverse is set.
+func unswizzleRow(dst, src []byte, bytesPerPixel int, reverse bool) {
+	i, step := 0, bytesPerPixel
+	if reverse {
+		i, step = len(dst)-bytesPerPixel, -bytesPerPixel
+	}
+
+	switch bytesPerPixel {
+	case 2:
+		for j := 0; j+3 < len(src); i, j = i+step, j+4 {
+			v := uint16(src[j+0]>>3)<<10 | uint16(src[j+1]>>3)<<5 | uint16(src[j+2]>>3)
+			if src[j+3] >= 0x80 {
+				v |= 0x8000
+			}
+
+			dst[i+0] = byte(v)
+			dst[i+1] = byte(v >> 8)
+		}
+	case 3:
+		for j := 0; j+3 < len(src); i, j = i+step, j+4 {
+			dst[i+0] = src[j+2]
+			dst[i+1] = src[j+1]
+			dst[i+2] = src[j+0]
+		}
+	case 4:
+		for j := 0; j+3 < len(src); i, j = i+step, j+4 {
+			dst[i+0] = src[j+2]
+			dst[i+1] = src[j+1]
+			dst[i+2] = src[j+0]
+			dst[i+3] = src[j+3]
+		}
+	}
+}
+
+// encodeRLE appends the row of stored pixels to dst as run-length encoded
+// packets. Packets never cross the end of the row.
+func encodeRLE(dst, row []byte, bytesPerPixel int) []byte {
+	n := len(row) / bytesPerPixel
+
+	pixel := func(i int) []byte {
+		return row[i*bytesPerPixel : (i+1)*bytesPerPixel]
+	}
+
+	for i := 0; i < n; {
+		run := 1
+		for i+run < n && run < 128 && bytes.Equal(pixel(i), pixel(i+run)) {
+			run++
+		}
+
+		if run > 1 {
+			dst = append(dst, 0x80|byte(run-1))
+			dst = append(dst, pixel(i)...)
+			i += run
+			continue
+		}
+
+		// raw packet, up to the next pixel starting a run
+		j := i + 1
+		for j < n && j-i < 128 && !(j+1 < n && bytes.Equal(pixel(j), pixel(j+1))) {
+			j++
+		}
+
+		dst = append(dst, byte(j-i-1))
+		dst = append(dst, row[i*bytesPerPixel:j*bytesPerPixel]...)
+		i = j
+	}
+
+	return dst
+}
@@ -0,0 +1,416 @@
+package tga
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRowWriter(t *testing.T) {
+	testCases := []struct {
+		header Header
+		opts   *EncodeOptions
+		ext    *ExtensionArea // as written
+	}{
+		{
+			header: Header{ImageType: UncompressedRGBImage, Width: 3, Height: 2, BitsPerPixel: 24, ImageDescriptor: 32},
+			opts:   nil,
+		},
+		{
+			header: Header{ImageType: UncompressedRGBImage, Width: 3, Height: 2, BitsPerPixel: 32, ImageDescriptor: 48 | 8},
+			opts:   &EncodeOptions{ImageID: []byte("tga"), ExtensionArea: &ExtensionArea{GammaValue: [2]uint16{22, 10}}},
+			ext:    &ExtensionArea{ExtensionSize: extensionAreaLen, GammaValue: [2]uint16{22, 10}, AttributesType: UsefulAlpha},
+		},
+		{
+			header: Header{ImageType: UncompressedRGBImage, Width: 3, Height: 2, BitsPerPixel: 16, ImageDescriptor: 0},
+			opts:   &EncodeOptions{ExtensionArea: &ExtensionArea{}, ScanLineTable: true},
+			ext:    &ExtensionArea{ExtensionSize: extensionAreaLen, ScanLineOffset: 30},
+		},
+	}
+
+	rows := [][]byte{
+		{255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 255},
+		{255, 255, 255, 255, 0, 0, 0, 255, 255, 0, 255, 255},
+	}
+
+	for i, tc := range testCases {
+		var (
+			buf    bytes.Buffer
+			before ExtensionArea
+		)
+
+		if tc.opts != nil && tc.opts.ExtensionArea != nil {
+			before = *tc.opts.ExtensionArea
+		}
+
+		rw, err := NewRowWriter(&buf, tc.header, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		for _, row := range rows {
+			err = rw.WriteRow(row)
+			if err != nil {
+				t.Fatalf("test %d: unexpected error: %v", i+1, err)
+			}
+		}
+
+		err = rw.Close()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		file, err := Read(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		if file.Version() != NewTGA {
+			t.Errorf("test %d: expected `%s`, but got `%s`", i+1, NewTGA, file.Version())
+		}
+
+		if tc.opts != nil {
+			if !bytes.Equal(tc.opts.ImageID, file.Image.ID) {
+				t.Errorf("test %d: expected image ID `%s`, but got `%s`", i+1, tc.opts.ImageID, file.Image.ID)
+			}
+
+			if !reflect.DeepEqual(tc.ext, file.ExtensionArea) {
+				t.Errorf("test %d:\nexpected %+v,\nbut got %+v", i+1, tc.ext, file.ExtensionArea)
+			}
+
+			if tc.opts.ExtensionArea != nil && *tc.opts.ExtensionArea != before {
+				t.Errorf("test %d: the extension area given was modified to %+v", i+1, *tc.opts.ExtensionArea)
+			}
+
+			if tc.opts.ScanLineTable && !reflect.DeepEqual(ScanLineTable{18, 24}, file.ScanLineTable) {
+				t.Errorf("test %d: expected scan line table `%v`, but got `%v`", i+1, ScanLineTable{18, 24}, file.ScanLineTable)
+			}
+		}
+
+		img, err := Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		// rows are given in stored order
+		for y, row := range rows {
+			if tc.header.ImageDescriptor.ImageOrigin() == BottomLeft {
+				y = len(rows) - 1 - y
+			}
+
+			pix, stride := pixOf(img)
+
+			got := pix[y*stride:][:len(row)]
+			if !bytes.Equal(row, got) {
+				t.Errorf("test %d: row %d: expected `%v`, but got `%v`", i+1, y, row, got)
+			}
+		}
+	}
+}
+
+func TestNewRowWriterInvalidHeader(t *testing.T) {
+	testCases := []Header{
+		{ImageType: UncompressedRGBImage, Width: 0, Height: 1, BitsPerPixel: 24},
+		{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 24, ImageDescriptor: 8},
+		{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 32, ImageDescriptor: 0xc0},
+		{ImageType: UncompressedRGBImage, Width: 1, Height: 2, BitsPerPixel: 24, ImageDescriptor: 0x40},
+		{ImageType: RunLengthEncodedRGBImage, Width: 1, Height: 4, BitsPerPixel: 32, ImageDescriptor: 0x80 | 8},
+	}
+
+	for i, header := range testCases {
+		if _, err := NewRowWriter(io.Discard, header, nil); err == nil {
+			t.Errorf("test %d: expected an error for header %+v", i+1, header)
+		}
+	}
+}
+
+func TestRowWriterRowCount(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 2, BitsPerPixel: 24}
+
+	var buf bytes.Buffer
+
+	rw, err := NewRowWriter(&buf, header, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rw.WriteRow([]byte{1, 2, 3, 255})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := rw.Close(); err == nil {
+		t.Errorf("expected an error when closing before every row is written")
+	}
+
+	err = rw.WriteRow([]byte{1, 2, 3, 255})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := rw.WriteRow([]byte{1, 2, 3, 255}); err == nil {
+		t.Errorf("expected an error when writing more rows than the header has")
+	}
+}
+
+func TestEncodeRLE(t *testing.T) {
+	testCases := []struct {
+		row           []byte
+		bytesPerPixel int
+		expected      []byte
+	}{
+		{
+			row:           []byte{1, 1, 1, 1},
+			bytesPerPixel: 1,
+			expected:      []byte{0x83, 1},
+		},
+		{
+			row:           []byte{1, 2, 3, 4},
+			bytesPerPixel: 1,
+			expected:      []byte{0x03, 1, 2, 3, 4},
+		},
+		{
+			row:           []byte{1, 2, 2, 2, 3},
+			bytesPerPixel: 1,
+			expected:      []byte{0x00, 1, 0x82, 2, 0x00, 3},
+		},
+		{
+			row:           []byte{1, 2, 1, 2, 3, 4},
+			bytesPerPixel: 2,
+			expected:      []byte{0x81, 1, 2, 0x00, 3, 4},
+		},
+		{
+			row:           bytes.Repeat([]byte{7}, 130),
+			bytesPerPixel: 1,
+			expected:      []byte{0xff, 7, 0x81, 7},
+		},
+	}
+
+	for i, tc := range testCases {
+		got := encodeRLE(nil, tc.row, tc.bytesPerPixel)
+
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("test %d: expected `%v`, but got `%v`", i+1, tc.expected, got)
+		}
+	}
+}
+
+func TestRowWriterRunLengthEncoded(t *testing.T) {
+	header := Header{ImageType: RunLengthEncodedRGBImage, Width: 3, Height: 1, BitsPerPixel: 24, ImageDescriptor: 32}
+
+	var buf bytes.Buffer
+
+	rw, err := NewRowWriter(&buf, header, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rw.WriteRow([]byte{1, 2, 3, 255, 1, 2, 3, 255, 4, 5, 6, 255})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []byte{0x81, 3, 2, 1, 0x00, 6, 5, 4}
+
+	got := buf.Bytes()[headerLen : buf.Len()-footerLen]
+	if !bytes.Equal(expected, got) {
+		t.Errorf("expected packets `%v`, but got `%v`", expected, got)
+	}
+}
+
+func TestRowWriterPremultiplied(t *testing.T) {
+	header := Header{ImageType: UncompressedRGBImage, Width: 1, Height: 1, BitsPerPixel: 32, ImageDescriptor: 32 | 8}
+
+	var buf bytes.Buffer
+
+	rw, err := NewRowWriter(&buf, header, &EncodeOptions{Premultiplied: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rw.WriteRow([]byte{200, 100, 50, 128})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to Read file: %v", err)
+	}
+
+	if file.ExtensionArea == nil || file.ExtensionArea.AttributesType != PremultipliedAlpha {
+		t.Fatalf("expected the attributes type to be premultiplied alpha, but got %+v", file.ExtensionArea)
+	}
+
+	expected := []byte{25, 50, 100, 128}
+	if !bytes.Equal(expected, file.Image.Data) {
+		t.Errorf("expected stored pixels `%v`, but got `%v`", expected, file.Image.Data)
+	}
+
+	img, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := img.(*image.RGBA); !ok {
+		t.Errorf("expected an *image.RGBA for premultiplied alpha, but got %T", img)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(10, 20, 12, 21))
+	opaque.Set(10, 20, color.RGBA{R: 1, G: 2, B: 3, A: 255})
+	opaque.Set(11, 20, color.RGBA{R: 4, G: 5, B: 6, A: 255})
+
+	translucent := image.NewNRGBA(image.Rect(0, 0, 1, 2))
+	translucent.Set(0, 1, color.NRGBA{R: 7, G: 8, B: 9, A: 128})
+
+	testCases := []struct {
+		img          image.Image
+		opts         *EncodeOptions
+		bitsPerPixel byte
+	}{
+		{img: opaque, bitsPerPixel: 24},
+		{img: translucent, bitsPerPixel: 32},
+		{img: translucent, opts: &EncodeOptions{ExtensionArea: &ExtensionArea{}}, bitsPerPixel: 32},
+	}
+
+	for i, tc := range testCases {
+		var buf bytes.Buffer
+
+		err := Encode(&buf, tc.img, tc.opts)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		file, err := Read(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		if file.Header.BitsPerPixel != tc.bitsPerPixel {
+			t.Errorf("test %d: expected %d bits per pixel, but got %d", i+1, tc.bitsPerPixel, file.Header.BitsPerPixel)
+		}
+
+		if file.Header.Origin() != tc.img.Bounds().Min {
+			t.Errorf("test %d: expected origin %v, but got %v", i+1, tc.img.Bounds().Min, file.Header.Origin())
+		}
+
+		got, err := DecodeWithOptions(bytes.NewReader(buf.Bytes()), &DecodeOptions{ScreenOrigin: true})
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		if got.Bounds() != tc.img.Bounds() {
+			t.Fatalf("test %d: expected bounds %v, but got %v", i+1, tc.img.Bounds(), got.Bounds())
+		}
+
+		b := tc.img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				expected := color.NRGBAModel.Convert(tc.img.At(x, y))
+				if c := color.NRGBAModel.Convert(got.At(x, y)); c != expected {
+					t.Errorf("test %d: expected %v at (%d, %d), but got %v", i+1, expected, x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeNegativeOrigin(t *testing.T) {
+	img := image.NewRGBA(image.Rect(-1, 0, 1, 1))
+
+	if err := Encode(io.Discard, img, nil); err == nil {
+		t.Errorf("expected an error for an origin outside of the header range")
+	}
+}
+
+func TestEncodePostageStamp(t *testing.T) {
+	testCases := []struct {
+		rect          image.Rectangle
+		width, height int
+	}{
+		{rect: image.Rect(0, 0, 3, 2), width: 3, height: 2},
+		{rect: image.Rect(0, 0, 64, 64), width: 64, height: 64},
+		{rect: image.Rect(0, 0, 130, 65), width: 64, height: 32},
+		{rect: image.Rect(0, 0, 1, 200), width: 1, height: 64},
+	}
+
+	for i, tc := range testCases {
+		img := image.NewRGBA(tc.rect)
+		for y := 0; y < tc.rect.Dy(); y++ {
+			for x := 0; x < tc.rect.Dx(); x++ {
+				img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 7, A: 255})
+			}
+		}
+
+		var buf bytes.Buffer
+
+		err := Encode(&buf, img, &EncodeOptions{PostageStamp: true})
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+
+		file, err := Read(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("test %d: failed to Read file: %v", i+1, err)
+		}
+
+		ext := file.ExtensionArea
+		if ext == nil || ext.PostageStampOffset == 0 {
+			t.Fatalf("test %d: expected a postage stamp offset, but got %+v", i+1, ext)
+		}
+
+		// stored like the image: top-down, BGR
+		stamp := buf.Bytes()[ext.PostageStampOffset:]
+		if int(stamp[0]) != tc.width || int(stamp[1]) != tc.height {
+			t.Fatalf("test %d: expected a %dx%d postage stamp, but got %dx%d", i+1, tc.width, tc.height, stamp[0], stamp[1])
+		}
+
+		pixels := stamp[postageStampHeaderLen:]
+		for y := 0; y < tc.height; y++ {
+			for x := 0; x < tc.width; x++ {
+				expected := []byte{7, uint8(y * tc.rect.Dy() / tc.height), uint8(x * tc.rect.Dx() / tc.width)}
+
+				got := pixels[(y*tc.width+x)*3:][:3]
+				if !bytes.Equal(expected, got) {
+					t.Errorf("test %d: pixel (%d, %d): expected `%v`, but got `%v`", i+1, x, y, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeScanLineTable(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 4))
+
+	var buf bytes.Buffer
+
+	err := Encode(&buf, img, &EncodeOptions{ScanLineTable: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to Read file: %v", err)
+	}
+
+	expected := ScanLineTable{18, 30, 42, 54}
+	if !reflect.DeepEqual(expected, file.ScanLineTable) {
+		t.Errorf("expected scan line table %v, but got %v", expected, file.ScanLineTable)
+	}
+}